@@ -0,0 +1,154 @@
+package ast
+
+import "slices"
+
+// Clone returns a deep copy of stmt, so the copy can be placed or mutated without affecting the original.
+// Tokens and literal values are copied by value, resolutions are kept.
+func Clone(stmt Stmt) Stmt {
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		return &ExpressionStatement{Expression: CloneExpr(s.Expression)}
+	case *PrintStatement:
+		return &PrintStatement{Expression: CloneExpr(s.Expression)}
+	case *VarStatement:
+		return &VarStatement{Name: s.Name, Initializer: CloneExpr(s.Initializer)}
+	case *BlockStatement:
+		return cloneBlock(s)
+	case *IfStatement:
+		return &IfStatement{
+			Condition:  CloneExpr(s.Condition),
+			ThenBranch: Clone(s.ThenBranch),
+			ElseBranch: Clone(s.ElseBranch),
+		}
+	case *WhileStatement:
+		return &WhileStatement{Keyword: s.Keyword, Condition: CloneExpr(s.Condition), Body: Clone(s.Body)}
+	case *FunctionStatement:
+		return cloneFunction(s)
+	case *ReturnStatement:
+		return &ReturnStatement{Keyword: s.Keyword, Value: CloneExpr(s.Value)}
+	case *ClassStatement:
+		clone := &ClassStatement{Name: s.Name}
+		if s.Superclass != nil {
+			clone.Superclass = CloneExpr(s.Superclass).(*VariableExpression)
+		}
+		for _, method := range s.Methods {
+			clone.Methods = append(clone.Methods, cloneFunction(method))
+		}
+		for _, method := range s.StaticMethods {
+			clone.StaticMethods = append(clone.StaticMethods, cloneFunction(method))
+		}
+		return clone
+	case *DeferStatement:
+		return &DeferStatement{Keyword: s.Keyword, Expression: CloneExpr(s.Expression)}
+	case *RepeatStatement:
+		return &RepeatStatement{Keyword: s.Keyword, Count: CloneExpr(s.Count), Body: Clone(s.Body)}
+	case *ForStatement:
+		return &ForStatement{
+			Keyword:     s.Keyword,
+			Initializer: Clone(s.Initializer),
+			Condition:   CloneExpr(s.Condition),
+			Increment:   CloneExpr(s.Increment),
+			Body:        Clone(s.Body),
+		}
+	case *BreakStatement:
+		return &BreakStatement{Keyword: s.Keyword}
+	case *ContinueStatement:
+		return &ContinueStatement{Keyword: s.Keyword}
+	case nil:
+		return nil
+	default:
+		panic("can't clone unknown statement")
+	}
+}
+
+// CloneExpr returns a deep copy of expr, see Clone
+func CloneExpr(expr Expr) Expr {
+	switch e := expr.(type) {
+	case *BinaryExpression:
+		return &BinaryExpression{Left: CloneExpr(e.Left), Operator: e.Operator, Right: CloneExpr(e.Right)}
+	case *GroupingExpression:
+		return &GroupingExpression{Expression: CloneExpr(e.Expression)}
+	case *LiteralExpression:
+		clone := *e
+		return &clone
+	case *UnaryExpression:
+		return &UnaryExpression{Operator: e.Operator, Right: CloneExpr(e.Right)}
+	case *CommaExpression:
+		return &CommaExpression{Expressions: cloneExprs(e.Expressions)}
+	case *ConditionExpression:
+		return &ConditionExpression{
+			Predicate:   CloneExpr(e.Predicate),
+			Consequent:  CloneExpr(e.Consequent),
+			Alternative: CloneExpr(e.Alternative),
+		}
+	case *VariableExpression:
+		clone := *e
+		return &clone
+	case *AssignExpression:
+		return &AssignExpression{Name: e.Name, Value: CloneExpr(e.Value), Resolution: e.Resolution}
+	case *PostfixExpression:
+		return &PostfixExpression{Target: CloneExpr(e.Target), Operator: e.Operator}
+	case *LogicalExpression:
+		return &LogicalExpression{Left: CloneExpr(e.Left), Operator: e.Operator, Right: CloneExpr(e.Right)}
+	case *CallExpression:
+		return &CallExpression{Callee: CloneExpr(e.Callee), Paren: e.Paren, Arguments: cloneExprs(e.Arguments)}
+	case *FunctionExpression:
+		return &FunctionExpression{Fun: e.Fun, Parameters: slices.Clone(e.Parameters), Body: cloneBlock(e.Body)}
+	case *GetExpression:
+		return &GetExpression{Object: CloneExpr(e.Object), Name: e.Name}
+	case *SetExpression:
+		return &SetExpression{Object: CloneExpr(e.Object), Name: e.Name, Value: CloneExpr(e.Value)}
+	case *ThisExpression:
+		clone := *e
+		return &clone
+	case *SuperExpression:
+		clone := *e
+		return &clone
+	case *MatchExpression:
+		clone := &MatchExpression{Keyword: e.Keyword, Subject: CloneExpr(e.Subject)}
+		for _, arm := range e.Arms {
+			armClone := &MatchArm{Pattern: CloneExpr(arm.Pattern), Guard: CloneExpr(arm.Guard), Body: CloneExpr(arm.Body)}
+			if arm.Binding != nil {
+				armClone.Binding = CloneExpr(arm.Binding).(*VariableExpression)
+			}
+			clone.Arms = append(clone.Arms, armClone)
+		}
+		return clone
+	case nil:
+		return nil
+	default:
+		panic("can't clone unknown expression")
+	}
+}
+
+func cloneBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+
+	statements := make([]Stmt, len(block.Statements))
+	for i, statement := range block.Statements {
+		statements[i] = Clone(statement)
+	}
+	return &BlockStatement{Statements: statements}
+}
+
+func cloneFunction(function *FunctionStatement) *FunctionStatement {
+	return &FunctionStatement{
+		Name:       function.Name,
+		Parameters: slices.Clone(function.Parameters),
+		Body:       cloneBlock(function.Body),
+	}
+}
+
+func cloneExprs(expressions []Expr) []Expr {
+	if expressions == nil {
+		return nil
+	}
+
+	clones := make([]Expr, len(expressions))
+	for i, expression := range expressions {
+		clones[i] = CloneExpr(expression)
+	}
+	return clones
+}
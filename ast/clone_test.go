@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+func TestCloneNestedBlock(t *testing.T) {
+	x := token.Token{Type: token.TokenTypeIdentifier, Lexeme: "x"}
+	original := &BlockStatement{Statements: []Stmt{
+		&VarStatement{
+			Name: x,
+			Initializer: &BinaryExpression{
+				Left:     &LiteralExpression{Value: float64(1)},
+				Operator: token.Token{Type: token.TokenTypePlus, Lexeme: "+"},
+				Right:    &LiteralExpression{Value: float64(2)},
+			},
+		},
+		&IfStatement{
+			Condition: &VariableExpression{Name: x, Resolution: Resolution{Local: true, Depth: 0}},
+			ThenBranch: &BlockStatement{Statements: []Stmt{
+				&PrintStatement{Expression: &VariableExpression{Name: x}},
+			}},
+		},
+	}}
+	printer := Printer{}
+	expected := printer.PrintStatement(original)
+
+	clone := Clone(original).(*BlockStatement)
+
+	if result := printer.PrintStatement(clone); result != expected {
+		t.Fatalf("Expected clone to print as %v, got %v", expected, result)
+	}
+	condition := clone.Statements[1].(*IfStatement).Condition.(*VariableExpression)
+	if condition.Resolution != (Resolution{Local: true, Depth: 0}) {
+		t.Errorf("Expected clone to keep the resolution, got %+v", condition.Resolution)
+	}
+
+	clone.Statements[0].(*VarStatement).Initializer.(*BinaryExpression).Left.(*LiteralExpression).Value = float64(10)
+	thenBranch := clone.Statements[1].(*IfStatement).ThenBranch.(*BlockStatement)
+	thenBranch.Statements[0] = &PrintStatement{Expression: &LiteralExpression{Value: "changed"}}
+	condition.Resolution.Depth = 3
+
+	if result := printer.PrintStatement(original); result != expected {
+		t.Errorf("Expected original to be unaffected, got %v", result)
+	}
+	if depth := original.Statements[1].(*IfStatement).Condition.(*VariableExpression).Resolution.Depth; depth != 0 {
+		t.Errorf("Expected original resolution to be unaffected, got depth %d", depth)
+	}
+}
+
+func TestCloneFunctionExpressionCopiesParameters(t *testing.T) {
+	original := &FunctionExpression{
+		Parameters: []token.Token{{Type: token.TokenTypeIdentifier, Lexeme: "a"}},
+		Body:       &BlockStatement{},
+	}
+
+	clone := CloneExpr(original).(*FunctionExpression)
+	clone.Parameters[0].Lexeme = "b"
+
+	if original.Parameters[0].Lexeme != "a" {
+		t.Errorf("Expected original parameters to be unaffected, got %v", original.Parameters[0].Lexeme)
+	}
+	if clone.Body == original.Body {
+		t.Errorf("Expected the body to be copied")
+	}
+}
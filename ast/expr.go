@@ -34,6 +34,8 @@ func (exp *GroupingExpression) Accept(visitor ExprVisitor) any {
 
 type LiteralExpression struct {
 	Value any
+	// the source token of the literal, it's zero for literals the parser synthesizes
+	Token token.Token
 }
 
 func (exp *LiteralExpression) Expr() {}
@@ -76,8 +78,18 @@ func (exp *ConditionExpression) Accept(visitor ExprVisitor) any {
 	return visitor.VisitConditionExpression(exp)
 }
 
+// Resolution is where the resolver found the variable an expression refers to. It's stored on the node,
+// so a copy of the node made by a transform after resolution still knows where to look.
+type Resolution struct {
+	// Local is false for globals, which are looked up by name
+	Local bool
+	// Depth is the number of scopes between the expression and the declaration of a local
+	Depth int
+}
+
 type VariableExpression struct {
-	Name token.Token
+	Name       token.Token
+	Resolution Resolution
 }
 
 func (exp *VariableExpression) Expr() {}
@@ -87,8 +99,9 @@ func (exp *VariableExpression) Accept(visitor ExprVisitor) any {
 }
 
 type AssignExpression struct {
-	Name  token.Token
-	Value Expr
+	Name       token.Token
+	Value      Expr
+	Resolution Resolution
 }
 
 func (exp *AssignExpression) Expr() {}
@@ -97,6 +110,20 @@ func (exp *AssignExpression) Accept(visitor ExprVisitor) any {
 	return visitor.VisitAssignExpression(exp)
 }
 
+// PostfixExpression is `target++` or `target--`, it evaluates the target once, stores the updated number
+// and yields the number it had before
+type PostfixExpression struct {
+	// a *VariableExpression or a *GetExpression
+	Target   Expr
+	Operator token.Token
+}
+
+func (exp *PostfixExpression) Expr() {}
+
+func (exp *PostfixExpression) Accept(visitor ExprVisitor) any {
+	return visitor.VisitPostfixExpression(exp)
+}
+
 // LogicalExpression represents a logical operation, such as AND or OR.
 // It is used to handle short-circuit evaluation in the interpreter.
 // That's why we can't use BinaryExpression for this purpose, as it does not support short-circuiting.
@@ -160,7 +187,8 @@ func (exp *SetExpression) Accept(visitor ExprVisitor) any {
 }
 
 type ThisExpression struct {
-	Keyword token.Token
+	Keyword    token.Token
+	Resolution Resolution
 }
 
 func (exp *ThisExpression) Expr() {}
@@ -170,8 +198,9 @@ func (exp *ThisExpression) Accept(visitor ExprVisitor) any {
 }
 
 type SuperExpression struct {
-	Keyword token.Token
-	Method  token.Token
+	Keyword    token.Token
+	Method     token.Token
+	Resolution Resolution
 }
 
 func (exp *SuperExpression) Expr() {}
@@ -180,6 +209,29 @@ func (exp *SuperExpression) Accept(visitor ExprVisitor) any {
 	return visitor.VisitSuperExpression(exp)
 }
 
+// MatchExpression evaluates to the body of the first arm whose pattern equals Subject,
+// like `match (x) { 1 => "one", _ => "other" }`
+type MatchExpression struct {
+	Keyword token.Token
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+// MatchArm is `pattern => body` or `pattern if guard => body`, a nil Pattern matches any value.
+// A name pattern like `n` leaves Pattern nil and binds the value to Binding in the scope of Guard and Body.
+type MatchArm struct {
+	Pattern Expr
+	Binding *VariableExpression
+	Guard   Expr
+	Body    Expr
+}
+
+func (exp *MatchExpression) Expr() {}
+
+func (exp *MatchExpression) Accept(visitor ExprVisitor) any {
+	return visitor.VisitMatchExpression(exp)
+}
+
 type ExprVisitor interface {
 	VisitBinaryExpression(expr *BinaryExpression) any
 	VisitGroupingExpression(expr *GroupingExpression) any
@@ -196,4 +248,6 @@ type ExprVisitor interface {
 	VisitSetExpression(expr *SetExpression) any
 	VisitThisExpression(expr *ThisExpression) any
 	VisitSuperExpression(expr *SuperExpression) any
+	VisitMatchExpression(expr *MatchExpression) any
+	VisitPostfixExpression(expr *PostfixExpression) any
 }
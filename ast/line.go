@@ -0,0 +1,206 @@
+package ast
+
+import "slices"
+
+// StmtLine returns the source line a statement starts on, or 0 when the statement has no token to tell
+func StmtLine(stmt Stmt) int {
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		return ExprLine(s.Expression)
+	case *PrintStatement:
+		return ExprLine(s.Expression)
+	case *VarStatement:
+		return s.Name.Line
+	case *BlockStatement:
+		if len(s.Statements) == 0 {
+			return 0
+		}
+		return StmtLine(s.Statements[0])
+	case *IfStatement:
+		return ExprLine(s.Condition)
+	case *WhileStatement:
+		return s.Keyword.Line
+	case *FunctionStatement:
+		return s.Name.Line
+	case *ReturnStatement:
+		return s.Keyword.Line
+	case *ClassStatement:
+		return s.Name.Line
+	case *DeferStatement:
+		return s.Keyword.Line
+	case *RepeatStatement:
+		return s.Keyword.Line
+	case *ForStatement:
+		return s.Keyword.Line
+	case *BreakStatement:
+		return s.Keyword.Line
+	case *ContinueStatement:
+		return s.Keyword.Line
+	default:
+		return 0
+	}
+}
+
+// ExprLine returns the source line of the leftmost token of an expression, or 0 when it has none
+func ExprLine(expr Expr) int {
+	switch e := expr.(type) {
+	case *BinaryExpression:
+		return firstLine(ExprLine(e.Left), e.Operator.Line)
+	case *GroupingExpression:
+		return ExprLine(e.Expression)
+	case *LiteralExpression:
+		return e.Token.Line
+	case *UnaryExpression:
+		return e.Operator.Line
+	case *CommaExpression:
+		if len(e.Expressions) == 0 {
+			return 0
+		}
+		return ExprLine(e.Expressions[0])
+	case *ConditionExpression:
+		return ExprLine(e.Predicate)
+	case *VariableExpression:
+		return e.Name.Line
+	case *AssignExpression:
+		return e.Name.Line
+	case *PostfixExpression:
+		return firstLine(ExprLine(e.Target), e.Operator.Line)
+	case *LogicalExpression:
+		return firstLine(ExprLine(e.Left), e.Operator.Line)
+	case *CallExpression:
+		return firstLine(ExprLine(e.Callee), e.Paren.Line)
+	case *FunctionExpression:
+		return e.Fun.Line
+	case *GetExpression:
+		return firstLine(ExprLine(e.Object), e.Name.Line)
+	case *SetExpression:
+		return firstLine(ExprLine(e.Object), e.Name.Line)
+	case *ThisExpression:
+		return e.Keyword.Line
+	case *SuperExpression:
+		return e.Keyword.Line
+	case *MatchExpression:
+		return e.Keyword.Line
+	default:
+		return 0
+	}
+}
+
+// firstLine returns line, or fallback when line is unknown
+func firstLine(line int, fallback int) int {
+	if line == 0 {
+		return fallback
+	}
+	return line
+}
+
+// StatementLines returns the sorted lines of all the statements in statements, nested ones included,
+// like the bodies of functions, methods and anonymous functions.
+func StatementLines(statements []Stmt) []int {
+	lines := make(map[int]bool)
+	for _, stmt := range statements {
+		collectStmtLines(stmt, lines)
+	}
+	delete(lines, 0)
+
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	slices.Sort(sorted)
+	return sorted
+}
+
+func collectStmtLines(stmt Stmt, lines map[int]bool) {
+	if stmt == nil {
+		return
+	}
+	lines[StmtLine(stmt)] = true
+
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		collectExprLines(s.Expression, lines)
+	case *PrintStatement:
+		collectExprLines(s.Expression, lines)
+	case *VarStatement:
+		collectExprLines(s.Initializer, lines)
+	case *BlockStatement:
+		for _, statement := range s.Statements {
+			collectStmtLines(statement, lines)
+		}
+	case *IfStatement:
+		collectExprLines(s.Condition, lines)
+		collectStmtLines(s.ThenBranch, lines)
+		collectStmtLines(s.ElseBranch, lines)
+	case *WhileStatement:
+		collectExprLines(s.Condition, lines)
+		collectStmtLines(s.Body, lines)
+	case *FunctionStatement:
+		collectStmtLines(s.Body, lines)
+	case *ReturnStatement:
+		collectExprLines(s.Value, lines)
+	case *ClassStatement:
+		for _, method := range s.Methods {
+			collectStmtLines(method.Body, lines)
+		}
+		for _, method := range s.StaticMethods {
+			collectStmtLines(method.Body, lines)
+		}
+	case *DeferStatement:
+		collectExprLines(s.Expression, lines)
+	case *RepeatStatement:
+		collectExprLines(s.Count, lines)
+		collectStmtLines(s.Body, lines)
+	case *ForStatement:
+		collectStmtLines(s.Initializer, lines)
+		collectExprLines(s.Condition, lines)
+		collectExprLines(s.Increment, lines)
+		collectStmtLines(s.Body, lines)
+	}
+}
+
+// collectExprLines looks for anonymous functions in expr, their bodies contain statements
+func collectExprLines(expr Expr, lines map[int]bool) {
+	switch e := expr.(type) {
+	case *FunctionExpression:
+		collectStmtLines(e.Body, lines)
+	case *BinaryExpression:
+		collectExprLines(e.Left, lines)
+		collectExprLines(e.Right, lines)
+	case *LogicalExpression:
+		collectExprLines(e.Left, lines)
+		collectExprLines(e.Right, lines)
+	case *GroupingExpression:
+		collectExprLines(e.Expression, lines)
+	case *UnaryExpression:
+		collectExprLines(e.Right, lines)
+	case *CommaExpression:
+		for _, expression := range e.Expressions {
+			collectExprLines(expression, lines)
+		}
+	case *ConditionExpression:
+		collectExprLines(e.Predicate, lines)
+		collectExprLines(e.Consequent, lines)
+		collectExprLines(e.Alternative, lines)
+	case *AssignExpression:
+		collectExprLines(e.Value, lines)
+	case *PostfixExpression:
+		collectExprLines(e.Target, lines)
+	case *CallExpression:
+		collectExprLines(e.Callee, lines)
+		for _, argument := range e.Arguments {
+			collectExprLines(argument, lines)
+		}
+	case *GetExpression:
+		collectExprLines(e.Object, lines)
+	case *SetExpression:
+		collectExprLines(e.Object, lines)
+		collectExprLines(e.Value, lines)
+	case *MatchExpression:
+		collectExprLines(e.Subject, lines)
+		for _, arm := range e.Arms {
+			collectExprLines(arm.Guard, lines)
+			collectExprLines(arm.Body, lines)
+		}
+	}
+}
@@ -2,17 +2,38 @@ package ast
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
 type Printer struct {
+	// prefix written once per nesting level before statements in a body, empty means no indentation
+	indent string
+	depth  int
 }
 
 func NewPrinter() *Printer {
 	return &Printer{}
 }
 
+// NewIndentedPrinter returns a Printer indenting block, function and class bodies by two spaces per level
+func NewIndentedPrinter() *Printer {
+	return &Printer{indent: "  "}
+}
+
+// writeBody writes each statement on its own line, indented one level deeper than the enclosing statement
+func (printer *Printer) writeBody(b *strings.Builder, statements []Stmt) {
+	printer.depth++
+	for _, s := range statements {
+		b.WriteString(strings.Repeat(printer.indent, printer.depth))
+		b.WriteString(printer.PrintStatement(s))
+		b.WriteString("\n")
+	}
+	printer.depth--
+	b.WriteString(strings.Repeat(printer.indent, printer.depth))
+}
+
 // Statement
 
 func (printer *Printer) PrintStatement(stmt Stmt) string {
@@ -35,10 +56,7 @@ func (printer *Printer) VisitVarStatement(stmt *VarStatement) any {
 func (printer *Printer) VisitBlockStatement(stmt *BlockStatement) any {
 	var b strings.Builder
 	b.WriteString("(begin\n")
-	for _, s := range stmt.Statements {
-		b.WriteString(printer.PrintStatement(s))
-		b.WriteString("\n")
-	}
+	printer.writeBody(&b, stmt.Statements)
 	b.WriteString(")")
 	return b.String()
 }
@@ -69,6 +87,48 @@ func (printer *Printer) VisitWhileStatement(stmt *WhileStatement) any {
 	return b.String()
 }
 
+func (printer *Printer) VisitRepeatStatement(stmt *RepeatStatement) any {
+	var b strings.Builder
+	b.WriteString("(repeat ")
+	b.WriteString(printer.PrintExpression(stmt.Count))
+
+	b.WriteString(" ")
+	b.WriteString(printer.PrintStatement(stmt.Body))
+	b.WriteString(")")
+	return b.String()
+}
+
+func (printer *Printer) VisitForStatement(stmt *ForStatement) any {
+	var b strings.Builder
+	b.WriteString("(for ")
+	if stmt.Initializer != nil {
+		b.WriteString(printer.PrintStatement(stmt.Initializer))
+	} else {
+		b.WriteString("_")
+	}
+	for _, clause := range []Expr{stmt.Condition, stmt.Increment} {
+		b.WriteString(" ")
+		if clause != nil {
+			b.WriteString(printer.PrintExpression(clause))
+		} else {
+			b.WriteString("_")
+		}
+	}
+
+	b.WriteString(" ")
+	b.WriteString(printer.PrintStatement(stmt.Body))
+	b.WriteString(")")
+	return b.String()
+}
+
+func (printer *Printer) VisitBreakStatement(stmt *BreakStatement) any {
+	return "(break)"
+}
+
+func (printer *Printer) VisitContinueStatement(stmt *ContinueStatement) any {
+	return "(continue)"
+}
+
 func (printer *Printer) VisitFunctionStatement(stmt *FunctionStatement) any {
 	var b strings.Builder
 	b.WriteString("(define (")
@@ -78,16 +138,15 @@ func (printer *Printer) VisitFunctionStatement(stmt *FunctionStatement) any {
 		b.WriteString(param.Lexeme)
 	}
 	b.WriteString(")\n")
-
-	for _, s := range stmt.Body.Statements {
-		b.WriteString(printer.PrintStatement(s))
-		b.WriteString("\n")
-	}
+	printer.writeBody(&b, stmt.Body.Statements)
 	b.WriteString(")")
 	return b.String()
 }
 
 func (printer *Printer) VisitReturnStatement(stmt *ReturnStatement) any {
+	if stmt.Value == nil {
+		return "(return)"
+	}
 	return fmt.Sprintf("(return %s)", stmt.Value.Accept(printer))
 }
 
@@ -100,15 +159,38 @@ func (printer *Printer) VisitClassStatement(stmt *ClassStatement) any {
 		b.WriteString(" < ")
 		b.WriteString(stmt.Superclass.Name.Lexeme)
 	}
+	if len(stmt.Methods) == 0 && len(stmt.StaticMethods) == 0 {
+		b.WriteString(")")
+		return b.String()
+	}
+
 	b.WriteString("\n")
+	methods := make([]Stmt, 0, len(stmt.Methods)+len(stmt.StaticMethods))
+	for _, method := range stmt.StaticMethods {
+		methods = append(methods, &staticMethod{method})
+	}
 	for _, method := range stmt.Methods {
-		b.WriteString(printer.PrintStatement(method))
-		b.WriteString("\n")
+		methods = append(methods, method)
 	}
+	printer.writeBody(&b, methods)
 	b.WriteString(")")
 	return b.String()
 }
 
+// staticMethod makes the printer write a static method as (static (define ...)) among the other methods
+type staticMethod struct {
+	*FunctionStatement
+}
+
+func (method *staticMethod) Accept(visitor StmtVisitor) any {
+	printer := visitor.(*Printer)
+	return fmt.Sprintf("(static %s)", printer.PrintStatement(method.FunctionStatement))
+}
+
+func (printer *Printer) VisitDeferStatement(stmt *DeferStatement) any {
+	return fmt.Sprintf("(defer %s)", printer.PrintExpression(stmt.Expression))
+}
+
 // Expression
 
 func (printer *Printer) PrintExpression(expr Expr) string {
@@ -133,7 +215,7 @@ func (printer *Printer) VisitLiteralExpression(expr *LiteralExpression) any {
 	if str, ok := expr.Value.(string); ok {
 		return str
 	} else if num, ok := expr.Value.(float64); ok {
-		return strconv.FormatFloat(num, 'f', -1, 64)
+		return FormatNumber(num)
 	} else {
 		return fmt.Sprintf("%v", expr.Value)
 	}
@@ -178,6 +260,10 @@ func (printer *Printer) VisitAssignExpression(expr *AssignExpression) any {
 	return fmt.Sprintf("(set! %s %s)", expr.Name.Lexeme, printer.PrintExpression(expr.Value))
 }
 
+func (printer *Printer) VisitPostfixExpression(expr *PostfixExpression) any {
+	return fmt.Sprintf("(post%s %s)", expr.Operator.Lexeme, printer.PrintExpression(expr.Target))
+}
+
 func (printer *Printer) VisitLogicalExpression(expr *LogicalExpression) any {
 	return fmt.Sprintf("(%s %s %s)",
 		expr.Operator.Lexeme,
@@ -235,3 +321,35 @@ func (printer *Printer) VisitThisExpression(expr *ThisExpression) any {
 func (printer *Printer) VisitSuperExpression(expr *SuperExpression) any {
 	return fmt.Sprintf("(super %s)", expr.Method.Lexeme)
 }
+
+// FormatNumber formats num in plain decimal notation, switching to scientific notation
+// for magnitudes from 1e21 up and below 1e-6, where the plain digits would be unreadable.
+func FormatNumber(num float64) string {
+	magnitude := math.Abs(num)
+	if num == 0 || (magnitude >= 1e-6 && magnitude < 1e21) {
+		return strconv.FormatFloat(num, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(num, 'g', -1, 64)
+}
+
+// (match x (1 one) (n if (> n 0) positive) (_ other))
+func (printer *Printer) VisitMatchExpression(expr *MatchExpression) any {
+	var b strings.Builder
+	b.WriteString("(match ")
+	b.WriteString(printer.PrintExpression(expr.Subject))
+
+	for _, arm := range expr.Arms {
+		pattern := "_"
+		if arm.Pattern != nil {
+			pattern = printer.PrintExpression(arm.Pattern)
+		} else if arm.Binding != nil {
+			pattern = arm.Binding.Name.Lexeme
+		}
+		if arm.Guard != nil {
+			pattern = fmt.Sprintf("%s if %s", pattern, printer.PrintExpression(arm.Guard))
+		}
+		b.WriteString(fmt.Sprintf(" (%s %s)", pattern, printer.PrintExpression(arm.Body)))
+	}
+	b.WriteString(")")
+	return b.String()
+}
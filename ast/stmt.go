@@ -19,6 +19,11 @@ type StmtVisitor interface {
 	VisitFunctionStatement(stmt *FunctionStatement) any
 	VisitReturnStatement(stmt *ReturnStatement) any
 	VisitClassStatement(stmt *ClassStatement) any
+	VisitDeferStatement(stmt *DeferStatement) any
+	VisitRepeatStatement(stmt *RepeatStatement) any
+	VisitForStatement(stmt *ForStatement) any
+	VisitBreakStatement(stmt *BreakStatement) any
+	VisitContinueStatement(stmt *ContinueStatement) any
 }
 
 type ExpressionStatement struct {
@@ -31,6 +36,8 @@ func (stmt *ExpressionStatement) Accept(visitor StmtVisitor) any {
 	return visitor.VisitExpressionStatement(stmt)
 }
 
+// PrintStatement prints the value of Expression, when it's a CommaExpression like in `print a, b;`
+// each of its values is printed, separated by spaces
 type PrintStatement struct {
 	Expression Expr
 }
@@ -75,6 +82,8 @@ func (stmt *IfStatement) Accept(visitor StmtVisitor) any {
 }
 
 type WhileStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword   token.Token
 	Condition Expr
 	Body      Stmt
 }
@@ -114,6 +123,8 @@ type ClassStatement struct {
 	// nil if no superclass
 	Superclass *VariableExpression
 	Methods    []*FunctionStatement
+	// methods declared as `class name() {}` in the body, they are called on the class instead of its instances
+	StaticMethods []*FunctionStatement
 }
 
 func (stmt *ClassStatement) Stmt() {}
@@ -121,3 +132,72 @@ func (stmt *ClassStatement) Stmt() {}
 func (stmt *ClassStatement) Accept(visitor StmtVisitor) any {
 	return visitor.VisitClassStatement(stmt)
 }
+
+// DeferStatement schedules Expression to run when the enclosing function returns
+type DeferStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword    token.Token
+	Expression Expr
+}
+
+func (stmt *DeferStatement) Stmt() {}
+
+func (stmt *DeferStatement) Accept(visitor StmtVisitor) any {
+	return visitor.VisitDeferStatement(stmt)
+}
+
+// RepeatStatement runs Body Count times, Count is evaluated once before the first iteration
+type RepeatStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword token.Token
+	Count   Expr
+	Body    Stmt
+}
+
+func (stmt *RepeatStatement) Stmt() {}
+
+func (stmt *RepeatStatement) Accept(visitor StmtVisitor) any {
+	return visitor.VisitRepeatStatement(stmt)
+}
+
+// ForStatement is a `for` loop, Initializer runs once in a scope of its own that every iteration gets a fresh copy of,
+// Increment runs after every iteration, including the ones cut short by `continue`
+type ForStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword token.Token
+	// nil when omitted, like the Condition and the Increment
+	Initializer Stmt
+	Condition   Expr
+	Increment   Expr
+	Body        Stmt
+}
+
+func (stmt *ForStatement) Stmt() {}
+
+func (stmt *ForStatement) Accept(visitor StmtVisitor) any {
+	return visitor.VisitForStatement(stmt)
+}
+
+// BreakStatement exits the innermost enclosing loop
+type BreakStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword token.Token
+}
+
+func (stmt *BreakStatement) Stmt() {}
+
+func (stmt *BreakStatement) Accept(visitor StmtVisitor) any {
+	return visitor.VisitBreakStatement(stmt)
+}
+
+// ContinueStatement skips the rest of the body of the innermost enclosing loop
+type ContinueStatement struct {
+	// keep Keyword, so we can use its location for error reporting
+	Keyword token.Token
+}
+
+func (stmt *ContinueStatement) Stmt() {}
+
+func (stmt *ContinueStatement) Accept(visitor StmtVisitor) any {
+	return visitor.VisitContinueStatement(stmt)
+}
@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+func TestEmptyClassStatement(t *testing.T) {
+	stmt := ClassStatement{
+		Name: token.Token{Type: token.TokenTypeIdentifier, Lexeme: "Foo"},
+	}
+	printer := Printer{}
+
+	result := printer.PrintStatement(&stmt)
+
+	if result != "(class Foo)" {
+		t.Fatalf("Expected '(class Foo)', got %v", result)
+	}
+}
+
+func TestClassStatementWithOnlySuperclass(t *testing.T) {
+	stmt := ClassStatement{
+		Name: token.Token{Type: token.TokenTypeIdentifier, Lexeme: "Foo"},
+		Superclass: &VariableExpression{
+			Name: token.Token{Type: token.TokenTypeIdentifier, Lexeme: "Bar"},
+		},
+	}
+	printer := Printer{}
+
+	result := printer.PrintStatement(&stmt)
+
+	if result != "(class Foo < Bar)" {
+		t.Fatalf("Expected '(class Foo < Bar)', got %v", result)
+	}
+}
+
+func TestClassStatementWithMethods(t *testing.T) {
+	stmt := ClassStatement{
+		Name: token.Token{Type: token.TokenTypeIdentifier, Lexeme: "Foo"},
+		Methods: []*FunctionStatement{
+			{
+				Name: token.Token{Type: token.TokenTypeIdentifier, Lexeme: "bar"},
+				Body: &BlockStatement{
+					Statements: []Stmt{
+						&PrintStatement{Expression: &LiteralExpression{Value: 123.0}},
+					},
+				},
+			},
+		},
+	}
+	printer := Printer{}
+
+	result := printer.PrintStatement(&stmt)
+
+	expected := "(class Foo\n(define (bar)\n(print 123)\n)\n)"
+	if result != expected {
+		t.Fatalf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestIndentedPrinter(t *testing.T) {
+	// fun foo(a) { if (a) { print a; } return a; }
+	a := token.Token{Type: token.TokenTypeIdentifier, Lexeme: "a"}
+	stmt := FunctionStatement{
+		Name:       token.Token{Type: token.TokenTypeIdentifier, Lexeme: "foo"},
+		Parameters: []token.Token{a},
+		Body: &BlockStatement{
+			Statements: []Stmt{
+				&IfStatement{
+					Condition: &VariableExpression{Name: a},
+					ThenBranch: &BlockStatement{
+						Statements: []Stmt{
+							&PrintStatement{Expression: &VariableExpression{Name: a}},
+						},
+					},
+				},
+				&ReturnStatement{Value: &VariableExpression{Name: a}},
+			},
+		},
+	}
+	printer := NewIndentedPrinter()
+
+	result := printer.PrintStatement(&stmt)
+
+	expected := "(define (foo a)\n  (if a (begin\n    (print a)\n  ))\n  (return a)\n)"
+	if result != expected {
+		t.Fatalf("Expected\n%s\ngot\n%s", expected, result)
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"bufio"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/ocowchun/go-lox/ast"
 	"github.com/ocowchun/go-lox/interpreter"
 	"github.com/ocowchun/go-lox/parser"
 	"io"
@@ -13,52 +15,126 @@ import (
 	"github.com/ocowchun/go-lox/lexer"
 )
 
+var debug = flag.Bool("debug", false, "pause before each statement and read debugger commands from stdin")
+var coverage = flag.Bool("coverage", false, "report which statement lines the script executed")
+var checkOnly = flag.Bool("check", false, "lex, parse and resolve the script without running it")
+var dumpAST = flag.Bool("dump-ast", false, "print the indented syntax tree of the script instead of running it")
+var warningsAsErrors = flag.Bool("warnings-as-errors", false, "fail on resolver warnings instead of printing them")
+var strictArity = flag.Bool("strict-arity", false, "report calls to top-level functions with the wrong number of arguments before running")
+
+// options are the settings of a run, main sets them from the flags
+type options struct {
+	// errOut receives errors and the REPL prompts, so they don't mix with what scripts print
+	errOut           io.Writer
+	debug            bool
+	coverage         bool
+	checkOnly        bool
+	dumpAST          bool
+	warningsAsErrors bool
+	strictArity      bool
+}
+
 func main() {
-	args := os.Args
-	if len(args) == 2 {
-		target := args[1]
-		runFile(target)
+	flag.CommandLine.SetOutput(os.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lox [flags] [script]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	} else if len(args) == 1 {
-		runPrompt()
+	opts := options{
+		errOut:           os.Stderr,
+		debug:            *debug,
+		coverage:         *coverage,
+		checkOnly:        *checkOnly,
+		dumpAST:          *dumpAST,
+		warningsAsErrors: *warningsAsErrors,
+		strictArity:      *strictArity,
+	}
+
+	args := flag.Args()
+	if len(args) == 1 {
+		target := args[0]
+		if code := runFile(target, opts); code != 0 {
+			os.Exit(code)
+		}
+
+	} else if len(args) == 0 {
+		runPrompt(opts)
 
 	} else {
-		fmt.Println("Usage: lox [script]")
+		flag.Usage()
 		os.Exit(64)
 	}
 }
 
-func runFile(target string) {
+// runFile runs the script at target and returns the exit code of the process
+func runFile(target string, opts options) int {
 	file, err := os.Open(target)
 	if err != nil {
-		fmt.Println("Error opening file:", err)
-		os.Exit(65)
+		fmt.Fprintln(opts.errOut, "Error opening file:", err)
+		return 65
 	}
 	defer file.Close()
 
-	err = run(file)
+	i := interpreter.New()
+	i.SetErrorOutput(opts.errOut)
+
+	if opts.dumpAST {
+		err = dump(file)
+		if err != nil {
+			i.ReportError(err)
+			return 65
+		}
+		return 0
+	}
+
+	if opts.checkOnly {
+		err = check(i, file, opts)
+		if err != nil {
+			i.ReportError(err)
+			return 65
+		}
+		return 0
+	}
+
+	if opts.debug {
+		i.SetDebugger(interpreter.NewDebugger(os.Stdin, opts.errOut))
+	}
+	if opts.coverage {
+		i.EnableCoverage()
+	}
+	statements, err := parse(file)
+	if err == nil {
+		err = execute(i, statements, opts)
+	}
+	if errors.Is(err, interpreter.ErrDebuggerQuit) {
+		return 0
+	}
+	if opts.coverage && statements != nil {
+		fmt.Fprintln(opts.errOut, i.Coverage(statements))
+	}
 
 	if err != nil {
-		var runtimeError *interpreter.RuntimeError
-		var resolverError *interpreter.ResolveError
+		i.ReportError(err)
 
-		if errors.As(err, &resolverError) {
-			fmt.Printf("%s\n[line %d]\n", resolverError.Message, resolverError.Token.Line)
-		} else if errors.As(err, &runtimeError) {
-			fmt.Printf("%s\n[line %d]\n", runtimeError.Message, runtimeError.Token.Line)
-			os.Exit(70)
-		} else {
-			fmt.Println(err)
-			os.Exit(65)
+		var runtimeError *interpreter.RuntimeError
+		if errors.As(err, &runtimeError) {
+			return 70
 		}
+		return 65
 	}
+	return 0
 }
 
-func runPrompt() {
+func runPrompt(opts options) {
 	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Println("Running REPL")
+	fmt.Fprintln(opts.errOut, "Running REPL")
+	// the interpreter is shared by all lines, so definitions persist across them
+	i := interpreter.New()
+	i.SetErrorOutput(opts.errOut)
 	for {
-		fmt.Print("> ")
+		fmt.Fprint(opts.errOut, "> ")
 		if !scanner.Scan() {
 			break
 		}
@@ -67,50 +143,163 @@ func runPrompt() {
 		if line == "exit" {
 			break
 		}
-		err := run(strings.NewReader(line))
-		if err != nil {
-			var runtimeError *interpreter.RuntimeError
-			var resolverError *interpreter.ResolveError
-			if errors.As(err, &resolverError) {
-				fmt.Printf("%s\n[line %d]\n", resolverError.Message, resolverError.Token.Line)
-			} else if errors.As(err, &runtimeError) {
-				fmt.Printf("%s\n[line %d]\n", runtimeError.Message, runtimeError.Token.Line)
-			} else {
-				fmt.Println(err)
+
+		var err error
+		if path, ok := strings.CutPrefix(line, ":load "); ok {
+			err = loadFile(i, strings.TrimSpace(path), opts)
+		} else if source, ok := strings.CutPrefix(line, ":type "); ok {
+			var typeName string
+			typeName, err = typeOf(i, source)
+			if err == nil {
+				fmt.Println(typeName)
 			}
+		} else {
+			err = run(i, strings.NewReader(line), opts)
+		}
+		if err != nil {
+			i.ReportError(err)
 		}
 	}
-	fmt.Println("Goodbye!")
+	fmt.Fprintln(opts.errOut, "Goodbye!")
+}
+
+// loadFile runs the file at path with i, so its definitions become available in the REPL
+func loadFile(i *interpreter.Interpreter, path string, opts options) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer file.Close()
+
+	return run(i, file, opts)
 }
 
-func run(r io.Reader) error {
+// typeOf evaluates the expression in source with i and returns the name of its type
+func typeOf(i *interpreter.Interpreter, source string) (string, error) {
+	tokens, err := lexer.New(source).Tokens()
+	if err != nil {
+		return "", fmt.Errorf("lexer error: %s", err)
+	}
+
+	expr, err := parser.NewParser(tokens).ParseExpression()
+	if err != nil {
+		return "", fmt.Errorf("parse error: %s", err)
+	}
+
+	err = interpreter.NewResolver(i).ResolveExpression(expr)
+	if err != nil {
+		return "", err
+	}
+
+	res := i.Evaluate(expr)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	return interpreter.TypeName(res.Value), nil
+}
+
+func run(i *interpreter.Interpreter, r io.Reader, opts options) error {
+	statements, err := parse(r)
+	if err != nil {
+		return err
+	}
+
+	return execute(i, statements, opts)
+}
+
+// execute resolves and runs the parsed statements with i
+func execute(i *interpreter.Interpreter, statements []ast.Stmt, opts options) error {
+	err := resolve(interpreter.NewResolver(i), statements, opts)
+	if err != nil {
+		return err
+	}
+
+	return i.Interpret(statements)
+}
+
+// check reports the lexing error, or every parsing or resolving error, of the source read from r, without running it
+func check(i *interpreter.Interpreter, r io.Reader, opts options) error {
+	statements, err := parse(r)
+	if err != nil {
+		return err
+	}
+
+	resolver := interpreter.NewResolver(i)
+	err = resolve(resolver, statements, opts)
+	if resolveErrors := resolver.Errors(); len(resolveErrors) > 1 {
+		return errors.Join(resolveErrors...)
+	}
+	return err
+}
+
+// resolve resolves statements with opts and prints the warnings
+func resolve(resolver *interpreter.Resolver, statements []ast.Stmt, opts options) error {
+	resolver.SetStrictArity(opts.strictArity)
+	resolver.SetWarningsAsErrors(opts.warningsAsErrors)
+	err := resolver.ResolveStatements(statements)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range resolver.Warnings() {
+		fmt.Fprintf(opts.errOut, "Warning: %s\n[line %d]\n", warning.Message, warning.Token.Line)
+	}
+	return nil
+}
+
+// dump prints the syntax tree of the source read from r
+func dump(r io.Reader) error {
+	statements, err := parse(r)
+	if err != nil {
+		return err
+	}
+
+	printer := ast.NewIndentedPrinter()
+	for _, stmt := range statements {
+		fmt.Println(printer.PrintStatement(stmt))
+	}
+	return nil
+}
+
+func parse(r io.Reader) ([]ast.Stmt, error) {
 	buf := new(strings.Builder)
 	_, err := io.Copy(buf, r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	lex := lexer.New(buf.String())
 
 	tokens, err := lex.Tokens()
 	if err != nil {
-		return fmt.Errorf("lexer error: %s", err)
+		return nil, fmt.Errorf("lexer error: %s", err)
 	}
 	p := parser.NewParser(tokens)
 
 	statements, err := p.Parse()
 	if err != nil {
-		return fmt.Errorf("parse error: %s", err)
+		return nil, describeParseErrors(err)
 	}
 
-	i := interpreter.New()
-	resolver := interpreter.NewResolver(i)
-	for _, stmt := range statements {
-		err = resolver.ResolveStatement(stmt)
-		if err != nil {
-			return err
-		}
+	return statements, nil
+}
+
+// describeParseErrors prefixes each syntax error joined in err, and follows it with its line
+func describeParseErrors(err error) error {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
 	}
 
-	return i.Interpret(statements)
+	described := make([]error, len(errs))
+	for i, err := range errs {
+		var parseError *parser.ParseError
+		if errors.As(err, &parseError) {
+			described[i] = fmt.Errorf("parse error: %w\n[line %d]", err, parseError.Token.Line)
+		} else {
+			described[i] = fmt.Errorf("parse error: %w", err)
+		}
+	}
+	return errors.Join(described...)
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ocowchun/go-lox/interpreter"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helpers.lox")
+	err := os.WriteFile(path, []byte("fun double(x) { return x * 2; }\nvar answer = double(21);\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	i := interpreter.New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+
+	err = loadFile(i, path, options{errOut: io.Discard})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = run(i, strings.NewReader("print double(answer);"), options{errOut: io.Discard})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if out.String() != "84\n" {
+		t.Errorf("Expected 84, got %q", out.String())
+	}
+}
+
+func TestLoadFile_MissingFile(t *testing.T) {
+	err := loadFile(interpreter.New(), filepath.Join(t.TempDir(), "missing.lox"), options{errOut: io.Discard})
+	if err == nil {
+		t.Fatalf("Expected error for a missing file")
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	i := interpreter.New()
+	err := run(i, strings.NewReader("class Foo {}"), options{errOut: io.Discard})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"1 + 2", "number"},
+		{`"a" + "b"`, "string"},
+		{"1 > 2", "boolean"},
+		{"nil", "nil"},
+		{"clock", "function"},
+		{"Foo", "class"},
+		{"Foo()", "instance"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.source, func(t *testing.T) {
+			actual, err := typeOf(i, testCase.source)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if actual != testCase.expected {
+				t.Errorf("Expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTypeOf_InvalidExpression(t *testing.T) {
+	_, err := typeOf(interpreter.New(), "1 +")
+	if err == nil {
+		t.Fatalf("Expected parse error")
+	}
+	if !strings.HasPrefix(err.Error(), "parse error:") {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+}
+
+func TestReportError_WritesToErrOut(t *testing.T) {
+	var stderr bytes.Buffer
+	i := interpreter.New()
+	i.SetErrorOutput(&stderr)
+	var stdout bytes.Buffer
+	i.SetOutput(&stdout)
+
+	err := run(i, strings.NewReader("print \"before\";\nnil + 1;"), options{errOut: &stderr})
+	if err == nil {
+		t.Fatalf("Expected a runtime error")
+	}
+	i.ReportError(err)
+
+	if stdout.String() != "before\n" {
+		t.Errorf("Expected only program output on stdout, got %q", stdout.String())
+	}
+	if !strings.HasSuffix(stderr.String(), "\n[line 2]\n") {
+		t.Errorf("Expected the formatted error on errOut, got %q", stderr.String())
+	}
+}
+
+func TestRunFile_Check(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := options{errOut: &stderr, checkOnly: true}
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.lox")
+	err := os.WriteFile(valid, []byte("print \"should not run\";\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.lox")
+	err = os.WriteFile(invalid, []byte("print 1;\nreturn 2;\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if code := runFile(valid, opts); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no diagnostics, got %q", stderr.String())
+	}
+
+	if code := runFile(invalid, opts); code == 0 {
+		t.Errorf("Expected a non-zero exit code")
+	}
+	if stderr.String() != "Can't return from top-level code.\n[line 2]\n" {
+		t.Errorf("Expected the resolve error, got %q", stderr.String())
+	}
+}
+
+func TestRunFile_CheckReportsEverySyntaxError(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := options{errOut: &stderr, checkOnly: true}
+
+	path := filepath.Join(t.TempDir(), "syntax.lox")
+	err := os.WriteFile(path, []byte("var x = ;\nprint 1;\nvar y = ;\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if code := runFile(path, opts); code == 0 {
+		t.Errorf("Expected a non-zero exit code")
+	}
+	lines := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+	if len(lines) != 4 || lines[1] != "[line 1]" || lines[3] != "[line 3]" {
+		t.Errorf("Expected both parse errors with their lines, got %q", stderr.String())
+	}
+	for _, line := range []string{lines[0], lines[2]} {
+		if !strings.HasPrefix(line, "parse error: ") {
+			t.Errorf("Expected a parse error, got %q", line)
+		}
+	}
+}
+
+func TestRunFile_CheckReportsEveryError(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := options{errOut: &stderr, checkOnly: true}
+
+	path := filepath.Join(t.TempDir(), "errors.lox")
+	err := os.WriteFile(path, []byte("return 1;\nprint 2;\nclass Foo < Foo {}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if code := runFile(path, opts); code == 0 {
+		t.Errorf("Expected a non-zero exit code")
+	}
+	expected := "Can't return from top-level code.\n[line 1]\nA class can't inherit from itself.\n[line 3]\n"
+	if stderr.String() != expected {
+		t.Errorf("Expected both resolve errors, got %q", stderr.String())
+	}
+}
+
+func TestRunFile_WarningsAsErrors(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := options{errOut: &stderr}
+
+	path := filepath.Join(t.TempDir(), "warning.lox")
+	err := os.WriteFile(path, []byte("fun sign(x) {\n  if (x > 0) return 1;\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	message := "Function 'sign' returns a value on some paths but falls off the end on others.\n[line 1]\n"
+
+	if code := runFile(path, opts); code != 0 {
+		t.Errorf("Expected exit code 0 without the flag, got %d", code)
+	}
+	if stderr.String() != "Warning: "+message {
+		t.Errorf("Expected the warning to be printed, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	opts.warningsAsErrors = true
+
+	if code := runFile(path, opts); code == 0 {
+		t.Errorf("Expected a non-zero exit code with the flag")
+	}
+	if stderr.String() != message {
+		t.Errorf("Expected the warning as an error, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,54 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+// StringBuilder is the runtime type returned by newBuilder(), it assembles text without
+// copying the whole string on every append like `+` does
+type StringBuilder struct {
+	builder strings.Builder
+}
+
+func NewStringBuilder() *StringBuilder {
+	return &StringBuilder{}
+}
+
+func (b *StringBuilder) Get(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "append":
+		return NewGoFunc(1, func(args []any) (any, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return nil, NewRuntimeError(name, fmt.Sprintf("append expects a string, got %s", TypeName(args[0])))
+			}
+			b.builder.WriteString(s)
+			return b, nil
+		}), nil
+	case "toString":
+		return NewGoFunc(0, func(args []any) (any, error) {
+			return b.builder.String(), nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("undefined property '%s' in builder", name.Lexeme)
+	}
+}
+
+func (b *StringBuilder) String() string {
+	return "<builder>"
+}
+
+// newBuilder() returns an empty StringBuilder
+type newBuilderFunction struct {
+}
+
+func (f *newBuilderFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	return EvaluatedResult{Value: NewStringBuilder()}
+}
+
+func (f *newBuilderFunction) Arity() int {
+	return 0
+}
@@ -0,0 +1,445 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+func defineBuiltins(globals *Environment) {
+	globals.Define("clock", &clockFunction{})
+	globals.Define("getClass", &getClassFunction{})
+	globals.Define("className", &classNameFunction{})
+	globals.Define("str", &strFunction{})
+	globals.Define("hash", &hashFunction{})
+	globals.Define("typeof", &typeofFunction{})
+	globals.Define("print", &printFunction{})
+	globals.Define("newBuilder", &newBuilderFunction{})
+	globals.Define("getField", &getFieldFunction{})
+	globals.Define("setField", &setFieldFunction{})
+	globals.Define("has", &hasFunction{})
+	globals.Define("removeField", &removeFieldFunction{})
+	globals.Define("remove", &removeFunction{})
+	globals.Define("clamp", &clampFunction{})
+	globals.Define("keys", &keysFunction{})
+	globals.Define("num", &numFunction{})
+	globals.Define("assertEqual", &assertEqualFunction{})
+	globals.Define("benchmark", &benchmarkFunction{})
+}
+
+// builtins don't know where they are called from, so the error only carries the builtin name
+func newBuiltinError(name string, message string) EvaluatedResult {
+	return EvaluatedResult{
+		Error: NewRuntimeError(
+			token.Token{Type: token.TokenTypeIdentifier, Lexeme: name},
+			message,
+		),
+	}
+}
+
+type clockFunction struct {
+}
+
+func (c *clockFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	return EvaluatedResult{
+		Value: float64(interpreter.now().Unix()),
+	}
+}
+
+func (c *clockFunction) Arity() int {
+	return 0
+}
+
+// benchmark(fn) calls fn without arguments and returns how many seconds the call took
+type benchmarkFunction struct {
+}
+
+func (f *benchmarkFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	callable, ok := args[0].(Callable)
+	if !ok || callable.Arity() != 0 {
+		return newBuiltinError("benchmark", fmt.Sprintf("benchmark expects a function without parameters, got %s", TypeName(args[0])))
+	}
+
+	start := interpreter.now()
+	res := callable.Call(interpreter, nil)
+	if res.Error != nil {
+		return res
+	}
+
+	return EvaluatedResult{Value: interpreter.now().Sub(start).Seconds()}
+}
+
+func (f *benchmarkFunction) Arity() int {
+	return 1
+}
+
+// getClass(instance) returns the class of an instance
+type getClassFunction struct {
+}
+
+func (f *getClassFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	instance, ok := args[0].(*Instance)
+	if !ok {
+		return newBuiltinError("getClass", fmt.Sprintf("getClass expects an instance, got %T", args[0]))
+	}
+
+	return EvaluatedResult{
+		Value: instance.class,
+	}
+}
+
+func (f *getClassFunction) Arity() int {
+	return 1
+}
+
+// className(classOrInstance) returns the name of a class, or the name of the class of an instance
+type classNameFunction struct {
+}
+
+func (f *classNameFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	switch value := args[0].(type) {
+	case *Class:
+		return EvaluatedResult{Value: value.name}
+	case *Instance:
+		return EvaluatedResult{Value: value.class.name}
+	default:
+		return newBuiltinError("className", fmt.Sprintf("className expects a class or an instance, got %T", args[0]))
+	}
+}
+
+func (f *classNameFunction) Arity() int {
+	return 1
+}
+
+// getField(instance, name) reads the property of instance whose name is computed at runtime
+type getFieldFunction struct {
+}
+
+func (f *getFieldFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	instance, ok := args[0].(*Instance)
+	if !ok {
+		return newBuiltinError("getField", fmt.Sprintf("getField expects an instance, got %s", TypeName(args[0])))
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return newBuiltinError("getField", fmt.Sprintf("getField expects a string name, got %s", TypeName(args[1])))
+	}
+
+	value, err := instance.Get(fieldToken(name))
+	if err != nil {
+		return newBuiltinError("getField", err.Error())
+	}
+
+	return EvaluatedResult{Value: value}
+}
+
+func (f *getFieldFunction) Arity() int {
+	return 2
+}
+
+// setField(instance, name, value) writes the property of instance whose name is computed at runtime
+type setFieldFunction struct {
+}
+
+func (f *setFieldFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	instance, ok := args[0].(*Instance)
+	if !ok {
+		return newBuiltinError("setField", fmt.Sprintf("setField expects an instance, got %s", TypeName(args[0])))
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return newBuiltinError("setField", fmt.Sprintf("setField expects a string name, got %s", TypeName(args[1])))
+	}
+
+	instance.Set(fieldToken(name), args[2])
+
+	return EvaluatedResult{Value: args[2]}
+}
+
+func (f *setFieldFunction) Arity() int {
+	return 3
+}
+
+// has(instance, name) reports whether instance has a field called name, methods don't count
+type hasFunction struct {
+}
+
+func (f *hasFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	instance, ok := args[0].(*Instance)
+	if !ok {
+		return newBuiltinError("has", fmt.Sprintf("has expects an instance, got %s", TypeName(args[0])))
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return newBuiltinError("has", fmt.Sprintf("has expects a string name, got %s", TypeName(args[1])))
+	}
+
+	_, exists := instance.fields[name]
+
+	return EvaluatedResult{Value: exists}
+}
+
+func (f *hasFunction) Arity() int {
+	return 2
+}
+
+// removeField(instance, name) deletes the field called name from instance, a missing field is ignored
+type removeFieldFunction struct {
+}
+
+func (f *removeFieldFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	instance, ok := args[0].(*Instance)
+	if !ok {
+		return newBuiltinError("removeField", fmt.Sprintf("removeField expects an instance, got %s", TypeName(args[0])))
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return newBuiltinError("removeField", fmt.Sprintf("removeField expects a string name, got %s", TypeName(args[1])))
+	}
+
+	delete(instance.fields, name)
+
+	return EvaluatedResult{}
+}
+
+func (f *removeFieldFunction) Arity() int {
+	return 2
+}
+
+// remove(map, key) deletes the entry of key from map, a missing key is ignored
+type removeFunction struct {
+}
+
+func (f *removeFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	m, ok := args[0].(*Map)
+	if !ok {
+		return newBuiltinError("remove", fmt.Sprintf("remove expects a map, got %s", TypeName(args[0])))
+	}
+
+	m.Delete(args[1])
+
+	return EvaluatedResult{}
+}
+
+func (f *removeFunction) Arity() int {
+	return 2
+}
+
+// num(string) parses a number, a malformed number throws a ThrowError
+type numFunction struct {
+}
+
+func (f *numFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	s, ok := args[0].(string)
+	if !ok {
+		return newBuiltinError("num", fmt.Sprintf("num expects a string, got %s", TypeName(args[0])))
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return EvaluatedResult{Error: NewThrowError(fmt.Sprintf("can't parse '%s' as a number", s))}
+	}
+
+	return EvaluatedResult{Value: number}
+}
+
+func (f *numFunction) Arity() int {
+	return 1
+}
+
+// assertEqual(actual, expected) and assertEqual(actual, expected, message) fail when actual != expected
+type assertEqualFunction struct {
+}
+
+func (f *assertEqualFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	actual, expected := args[0], args[1]
+	if isEqual(actual, expected) {
+		return EvaluatedResult{}
+	}
+
+	message := fmt.Sprintf("expected %s but got %s", stringify(expected), stringify(actual))
+	if len(args) == 3 {
+		message = fmt.Sprintf("%s: %s", stringify(args[2]), message)
+	}
+	return newBuiltinError("assertEqual", message)
+}
+
+func (f *assertEqualFunction) Arity() int {
+	return 2
+}
+
+func (f *assertEqualFunction) MaxArity() int {
+	return 3
+}
+
+// keys(map) returns a list of the keys of map in insertion order
+type keysFunction struct {
+}
+
+func (f *keysFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	m, ok := args[0].(*Map)
+	if !ok {
+		return newBuiltinError("keys", fmt.Sprintf("keys expects a map, got %s", TypeName(args[0])))
+	}
+
+	return EvaluatedResult{Value: NewList(m.Keys())}
+}
+
+func (f *keysFunction) Arity() int {
+	return 1
+}
+
+// clamp(value, lo, hi) bounds value to the range [lo, hi]
+type clampFunction struct {
+}
+
+func (f *clampFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	numbers := make([]float64, len(args))
+	for i, arg := range args {
+		number, ok := integerToFloat(arg).(float64)
+		if !ok {
+			return newBuiltinError("clamp", fmt.Sprintf("clamp expects numbers, got %s", TypeName(arg)))
+		}
+		numbers[i] = number
+	}
+
+	value, lo, hi := numbers[0], numbers[1], numbers[2]
+	if lo > hi {
+		return newBuiltinError("clamp", fmt.Sprintf("clamp expects lo <= hi, got %s > %s", stringify(args[1]), stringify(args[2])))
+	}
+
+	switch {
+	case value < lo:
+		return EvaluatedResult{Value: args[1]}
+	case value > hi:
+		return EvaluatedResult{Value: args[2]}
+	default:
+		return EvaluatedResult{Value: args[0]}
+	}
+}
+
+func (f *clampFunction) Arity() int {
+	return 3
+}
+
+// fieldToken synthesizes the token a property access `.name` would have
+func fieldToken(name string) token.Token {
+	return token.Token{Type: token.TokenTypeIdentifier, Lexeme: name}
+}
+
+// print(value, ...) is the function form of the print statement,
+// like `print a, b;` it displays its arguments separated by spaces
+type printFunction struct {
+}
+
+func (f *printFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = stringify(arg)
+	}
+
+	_, err := fmt.Fprintln(interpreter.stdout, strings.Join(values, " "))
+	if err != nil {
+		return newBuiltinError("print", err.Error())
+	}
+
+	return EvaluatedResult{}
+}
+
+func (f *printFunction) Arity() int {
+	return 1
+}
+
+func (f *printFunction) MaxArity() int {
+	return 255
+}
+
+// str(value) returns the text `print` would display for value
+type strFunction struct {
+}
+
+func (f *strFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	return EvaluatedResult{Value: stringify(args[0])}
+}
+
+func (f *strFunction) Arity() int {
+	return 1
+}
+
+// hash(value) returns a stable string key for value, it's what maps use to compare keys
+type hashFunction struct {
+}
+
+func (f *hashFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	return EvaluatedResult{Value: hashValue(args[0])}
+}
+
+func (f *hashFunction) Arity() int {
+	return 1
+}
+
+// hashValue returns equal hashes for equal primitives, other values are hashed by identity
+func hashValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool:" + strconv.FormatBool(v)
+	case float64:
+		return "number:" + strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		// integers from integer mode collide with the equal float, unless a float can't hold them exactly,
+		// then distinct integers rounding to the same float must keep distinct hashes
+		if f := float64(v); f < math.MaxInt64 && int64(f) == v {
+			return "number:" + strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return "number:" + strconv.FormatInt(v, 10)
+	case string:
+		return "string:" + v
+	default:
+		return fmt.Sprintf("%T:%p", v, v)
+	}
+}
+
+// typeof(value) returns the name of the Lox type of value
+type typeofFunction struct {
+}
+
+func (f *typeofFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	return EvaluatedResult{Value: TypeName(args[0])}
+}
+
+func (f *typeofFunction) Arity() int {
+	return 1
+}
+
+// TypeName returns the name of the Lox type of value, like number, string or instance
+func TypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64, int64:
+		return "number"
+	case string:
+		return "string"
+	case *Class:
+		return "class"
+	case *Instance:
+		return "instance"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *StringBuilder:
+		return "builder"
+	case Callable:
+		return "function"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
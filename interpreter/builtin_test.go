@@ -0,0 +1,565 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuiltin_GetClass(t *testing.T) {
+	code := `
+class Foo {}
+var foo = Foo();
+var fooClass = getClass(foo);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fooClass := getGlobal(t, i, "fooClass")
+	class, ok := fooClass.(*Class)
+	if !ok {
+		t.Fatalf("Expected *Class, got %T", fooClass)
+	}
+	if class.name != "Foo" {
+		t.Errorf("Expected class Foo, got %s", class.name)
+	}
+}
+
+func TestBuiltin_GetClassIdentityEquality(t *testing.T) {
+	code := `
+class Foo {}
+class Bar {}
+var foo1 = Foo();
+var foo2 = Foo();
+var bar = Bar();
+var same = getClass(foo1) == getClass(foo2);
+var different = getClass(foo1) == getClass(bar);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if same := getGlobal(t, i, "same"); same != true {
+		t.Errorf("Expected instances of the same class to have equal classes, got %v", same)
+	}
+	if different := getGlobal(t, i, "different"); different != false {
+		t.Errorf("Expected instances of different classes to have different classes, got %v", different)
+	}
+}
+
+func TestBuiltin_GetClassRejectsNonInstance(t *testing.T) {
+	_, err := interpretTestCode("getClass(123);")
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "getClass expects an instance, got float64" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestBuiltin_ClassName(t *testing.T) {
+	code := `
+class Foo {}
+var fromClass = className(Foo);
+var fromInstance = className(Foo());
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if name := getGlobal(t, i, "fromClass"); name != "Foo" {
+		t.Errorf("Expected Foo, got %v", name)
+	}
+	if name := getGlobal(t, i, "fromInstance"); name != "Foo" {
+		t.Errorf("Expected Foo, got %v", name)
+	}
+}
+
+func TestBuiltin_ClassNameRejectsOtherValues(t *testing.T) {
+	_, err := interpretTestCode(`className("Foo");`)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "className expects a class or an instance, got string" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestBuiltin_StrMatchesPrint(t *testing.T) {
+	code := `
+print true;
+print nil;
+print list;
+print str(true) + " " + str(nil) + " " + str(list);
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("list", NewList([]any{true, nil, 1.5, "a"}))
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "true\nnil\n[true, nil, 1.5, a]\ntrue nil [true, nil, 1.5, a]\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestBuiltin_Hash(t *testing.T) {
+	code := `
+class Foo {}
+var foo = Foo();
+var sameNumber = hash(1) == hash(1.0);
+var numberAndString = hash(1) == hash("1");
+var sameInstance = hash(foo) == hash(foo);
+var differentInstances = hash(foo) == hash(Foo());
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]bool{
+		"sameNumber":         true,
+		"numberAndString":    false,
+		"sameInstance":       true,
+		"differentInstances": false,
+	}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, actual)
+		}
+	}
+}
+
+// mapFunction is a host map(list, f) returning a new list of f applied to each element
+type mapFunction struct {
+}
+
+func (f *mapFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	list := args[0].(*List)
+	callable := args[1].(Callable)
+	mapped := make([]any, list.Len())
+	for i := range mapped {
+		result := callable.Call(interpreter, []any{list.Get(i)})
+		if result.Error != nil {
+			return result
+		}
+		mapped[i] = result.Value
+	}
+
+	return EvaluatedResult{Value: NewList(mapped)}
+}
+
+func (f *mapFunction) Arity() int {
+	return 2
+}
+
+func TestBuiltin_PrintFunction(t *testing.T) {
+	code := `
+print "statement";
+print(1 + 2);
+print (1 + 2) * 3;
+print(1, "two", nil);
+var results = map(list, print);
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("list", NewList([]any{"first", "second"}))
+	i.DefineGlobal("map", &mapFunction{})
+
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "statement\n3\n9\n1 two nil\nfirst\nsecond\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if results := stringify(getGlobal(t, i, "results")); results != "[nil, nil]" {
+		t.Errorf("Expected print to return nil for each element, got %s", results)
+	}
+}
+
+func TestBuiltin_NewBuilder(t *testing.T) {
+	code := `
+var builder = newBuilder();
+var naive = "";
+var i = 0;
+while (i < 500) {
+	builder.append("ab").append("c");
+	naive = naive + "abc";
+	i = i + 1;
+}
+var built = builder.toString();
+var same = built == naive;
+var kind = typeof(builder);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if built := getGlobal(t, i, "built"); len(built.(string)) != 1500 {
+		t.Errorf("Expected a 1500 character string, got %d characters", len(built.(string)))
+	}
+	if same := getGlobal(t, i, "same"); same != true {
+		t.Errorf("Expected the builder to match naive concatenation")
+	}
+	if kind := getGlobal(t, i, "kind"); kind != "builder" {
+		t.Errorf("Expected typeof to return builder, got %v", kind)
+	}
+}
+
+func TestBuiltin_NewBuilderAppendNonString(t *testing.T) {
+	_, err := interpretTestCode(`newBuilder().append(1);`)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "append expects a string, got number" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestBuiltin_GetFieldAndSetField(t *testing.T) {
+	code := `
+class Point {
+	init(x) {
+		this.x = x;
+	}
+
+	double() {
+		return this.x * 2;
+	}
+}
+var point = Point(1);
+var axis = "x";
+var x = getField(point, axis);
+var assigned = setField(point, "y", 2);
+var y = point.y;
+var doubled = getField(point, "dou" + "ble")();
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected any
+	}{
+		{"x", float64(1)},
+		{"assigned", float64(2)},
+		{"y", float64(2)},
+		{"doubled", float64(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := getGlobal(t, i, tt.name); value != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestBuiltin_GetFieldUndefined(t *testing.T) {
+	code := `
+class Point {}
+getField(Point(), "z");
+`
+
+	_, err := interpretTestCode(code)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "undefined property 'z' in instance of class 'Point'" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestBuiltin_Has(t *testing.T) {
+	code := `
+class Point {
+	init() {
+		this.x = nil;
+	}
+
+	y() {}
+}
+var point = Point();
+var hasX = has(point, "x");
+var hasY = has(point, "y");
+var hasZ = has(point, "z");
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"hasX", true},
+		{"hasY", false},
+		{"hasZ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := getGlobal(t, i, tt.name); value != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestBuiltin_RemoveField(t *testing.T) {
+	code := `
+class Point {
+	init() {
+		this.x = 1;
+	}
+}
+var point = Point();
+removeField(point, "x");
+removeField(point, "y");
+var hasX = has(point, "x");
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if hasX := getGlobal(t, i, "hasX"); hasX != false {
+		t.Errorf("Expected the field to be removed, got has = %v", hasX)
+	}
+}
+
+func TestBuiltin_Remove(t *testing.T) {
+	m := NewMap()
+	m.Set("a", float64(1))
+	m.Set("b", float64(2))
+
+	i := New()
+	i.DefineGlobal("m", m)
+	err := i.Interpret(parseCode(`remove(m, "a"); remove(m, "c");`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := m.Get("a"); ok {
+		t.Errorf("Expected key a to be removed")
+	}
+	if m.Len() != 1 {
+		t.Errorf("Expected 1 entry left, got %d", m.Len())
+	}
+}
+
+func TestBuiltin_Clamp(t *testing.T) {
+	code := `
+var below = clamp(-5, 0, 10);
+var within = clamp(3.5, 0, 10);
+var above = clamp(15, 0, 10);
+var bounds = clamp(10, 0, 10);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected float64
+	}{
+		{"below", 0},
+		{"within", 3.5},
+		{"above", 10},
+		{"bounds", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := getGlobal(t, i, tt.name); value != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestBuiltin_ClampErrors(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"clamp(1, 10, 0);", "clamp expects lo <= hi, got 10 > 0"},
+		{`clamp("1", 0, 10);`, "clamp expects numbers, got string"},
+		{"clamp(1, nil, 10);", "clamp expects numbers, got nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			_, err := interpretTestCode(tt.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, runtimeError.Message)
+			}
+		})
+	}
+}
+
+func TestBuiltin_Num(t *testing.T) {
+	i, err := interpretTestCode(`var n = num(" 12.5 ");`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if n := getGlobal(t, i, "n"); n != 12.5 {
+		t.Errorf("Expected 12.5, got %v", n)
+	}
+}
+
+func TestBuiltin_NumThrows(t *testing.T) {
+	code := `
+fun parse(s) {
+	return num(s);
+}
+
+parse("12a");
+`
+
+	_, err := interpretTestCode(code)
+
+	var throwError *ThrowError
+	if !errors.As(err, &throwError) {
+		t.Fatalf("Expected ThrowError, got %T", err)
+	}
+	if throwError.Value != "can't parse '12a' as a number" {
+		t.Errorf("Expected the thrown value, got %v", throwError.Value)
+	}
+	if throwError.Token.Line != 3 {
+		t.Errorf("Expected the error at the call site on line 3, got %d", throwError.Token.Line)
+	}
+
+	// a thrown error is still a runtime error
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "can't parse '12a' as a number" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestBuiltin_AssertEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{"passing", `assertEqual(1 + 2, 3); assertEqual("a", "a", "same strings");`, ""},
+		{"failing", "assertEqual(2 + 2, 3);", "expected 3 but got 4"},
+		{"failing with message", `assertEqual(nil, "foo", "lookup");`, "lookup: expected foo but got nil"},
+		{"too few arguments", "assertEqual(1);", "expected 2 to 3 arguments but got 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interpretTestCode(tt.code)
+			if tt.expected == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, runtimeError.Message)
+			}
+		})
+	}
+}
+
+func TestBuiltin_Benchmark(t *testing.T) {
+	code := `
+var calls = 0;
+fun work() {
+	calls = calls + 1;
+}
+var elapsed = benchmark(work);
+`
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	i := New()
+	i.SetClock(func() time.Time {
+		now = now.Add(1500 * time.Millisecond)
+		return now
+	})
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if elapsed := getGlobal(t, i, "elapsed"); elapsed != 1.5 {
+		t.Errorf("Expected 1.5 seconds, got %v", elapsed)
+	}
+	if calls := getGlobal(t, i, "calls"); calls != float64(1) {
+		t.Errorf("Expected the callback to be called once, got %v", calls)
+	}
+}
+
+func TestBuiltin_BenchmarkPropagatesErrors(t *testing.T) {
+	code := `
+benchmark(fun () {
+	return 1 + nil;
+});
+`
+
+	_, err := interpretTestCode(code)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Token.Lexeme != "+" {
+		t.Errorf("Expected the error of the callback, got %v", err)
+	}
+}
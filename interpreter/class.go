@@ -1,9 +1,17 @@
 package interpreter
 
+import (
+	"fmt"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
 type Class struct {
 	name       string
 	superclass *Class
 	methods    map[string]*Function
+	// methods called on the class itself, like `Math.square(2)`
+	staticMethods map[string]*Function
 }
 
 func NewClass(name string, superclass *Class, methods map[string]*Function) *Class {
@@ -50,3 +58,14 @@ func (c *Class) FindMethod(name string) *Function {
 
 	return nil
 }
+
+// Get looks up a static method, static methods of the superclasses are inherited
+func (c *Class) Get(name token.Token) (any, error) {
+	for class := c; class != nil; class = class.superclass {
+		if method, exists := class.staticMethods[name.Lexeme]; exists {
+			return method, nil
+		}
+	}
+
+	return nil, fmt.Errorf("undefined static member '%s' on class '%s'", name.Lexeme, c.name)
+}
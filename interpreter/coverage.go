@@ -0,0 +1,50 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ocowchun/go-lox/ast"
+)
+
+// Coverage tells which statement lines of a program were executed
+type Coverage struct {
+	Executed   []int
+	Unexecuted []int
+}
+
+// EnableCoverage makes the interpreter record the lines of the statements it executes
+func (interpreter *Interpreter) EnableCoverage() {
+	interpreter.executedLines = make(map[int]bool)
+}
+
+// Coverage compares the lines executed so far with the statement lines of program
+func (interpreter *Interpreter) Coverage(program []ast.Stmt) Coverage {
+	coverage := Coverage{
+		Executed:   []int{},
+		Unexecuted: []int{},
+	}
+	for _, line := range ast.StatementLines(program) {
+		if interpreter.executedLines[line] {
+			coverage.Executed = append(coverage.Executed, line)
+		} else {
+			coverage.Unexecuted = append(coverage.Unexecuted, line)
+		}
+	}
+
+	return coverage
+}
+
+// String formats the report like `coverage: 3/4 lines` followed by the unexecuted lines
+func (c Coverage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "coverage: %d/%d lines", len(c.Executed), len(c.Executed)+len(c.Unexecuted))
+	if len(c.Unexecuted) > 0 {
+		lines := make([]string, 0, len(c.Unexecuted))
+		for _, line := range c.Unexecuted {
+			lines = append(lines, fmt.Sprint(line))
+		}
+		fmt.Fprintf(&b, "\nunexecuted lines: %s", strings.Join(lines, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package interpreter
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestCoverage_NeverTakenBranch(t *testing.T) {
+	code := `var x = 1;
+if (x > 2) {
+  print "big";
+} else {
+  print "small";
+}
+`
+	interpreter := New()
+	var out bytes.Buffer
+	interpreter.SetOutput(&out)
+	interpreter.EnableCoverage()
+	statements := parseCode(code)
+	err := NewResolver(interpreter).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Failed to resolve code: %v", err)
+	}
+	err = interpreter.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Failed to interpret code: %v", err)
+	}
+
+	coverage := interpreter.Coverage(statements)
+	if !slices.Equal(coverage.Executed, []int{1, 2, 5}) {
+		t.Errorf("Expected lines 1, 2 and 5 to be executed, got %v", coverage.Executed)
+	}
+	if !slices.Equal(coverage.Unexecuted, []int{3}) {
+		t.Errorf("Expected line 3 to be unexecuted, got %v", coverage.Unexecuted)
+	}
+
+	expected := "coverage: 3/4 lines\nunexecuted lines: 3"
+	if coverage.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, coverage.String())
+	}
+}
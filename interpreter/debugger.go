@@ -0,0 +1,131 @@
+package interpreter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ocowchun/go-lox/ast"
+	"github.com/ocowchun/go-lox/token"
+)
+
+// ErrDebuggerQuit is returned by Interpret when the program is stopped with the `quit` debugger command
+var ErrDebuggerQuit = errors.New("quit from the debugger")
+
+// Debugger pauses the interpreter before statements and reads commands to inspect the program:
+//
+//	step           run the current statement and pause before the next one
+//	continue       run until a breakpoint
+//	break <line>   pause before the statements on line
+//	print <name>   show the value of a variable
+//	vars           show the variables in scope
+//	quit           stop the program
+type Debugger struct {
+	commands *bufio.Scanner
+	out      io.Writer
+
+	breakpoints map[int]bool
+	stepping    bool
+	// names defined before the program started, like the builtins, `vars` hides them
+	predefined map[string]bool
+}
+
+// NewDebugger returns a debugger reading commands from in and writing to out,
+// it pauses before the first statement.
+func NewDebugger(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		commands:    bufio.NewScanner(in),
+		out:         out,
+		breakpoints: make(map[int]bool),
+		stepping:    true,
+	}
+}
+
+func (d *Debugger) SetBreakpoint(line int) {
+	d.breakpoints[line] = true
+}
+
+// SetDebugger makes the interpreter consult d before every statement, a nil debugger turns debugging off
+func (interpreter *Interpreter) SetDebugger(d *Debugger) {
+	interpreter.debugger = d
+	if d != nil {
+		d.predefined = make(map[string]bool)
+		for _, name := range interpreter.GlobalNames() {
+			d.predefined[name] = true
+		}
+	}
+}
+
+// beforeStatement pauses before stmt when stepping or when it's on a breakpoint
+func (d *Debugger) beforeStatement(interpreter *Interpreter, stmt ast.Stmt) error {
+	line := ast.StmtLine(stmt)
+	if !d.stepping && !d.breakpoints[line] {
+		return nil
+	}
+
+	fmt.Fprintf(d.out, "[line %d] %s\n", line, ast.NewPrinter().PrintStatement(stmt))
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+		if !d.commands.Scan() {
+			// no more commands, let the program run to the end
+			d.stepping = false
+			d.breakpoints = map[int]bool{}
+			return nil
+		}
+
+		command, argument, _ := strings.Cut(strings.TrimSpace(d.commands.Text()), " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		case "step", "s":
+			d.stepping = true
+			return nil
+		case "continue", "c":
+			d.stepping = false
+			return nil
+		case "quit", "q":
+			return ErrDebuggerQuit
+		case "break", "b":
+			breakpoint, err := strconv.Atoi(argument)
+			if err != nil {
+				fmt.Fprintf(d.out, "invalid line %q\n", argument)
+				continue
+			}
+			d.SetBreakpoint(breakpoint)
+		case "print", "p":
+			value, err := interpreter.environment.Get(token.Token{Type: token.TokenTypeIdentifier, Lexeme: argument})
+			if err != nil {
+				fmt.Fprintln(d.out, err)
+				continue
+			}
+			fmt.Fprintln(d.out, stringify(value))
+		case "vars", "v":
+			d.printVariables(interpreter.environment)
+		default:
+			fmt.Fprintf(d.out, "unknown command %q, expected step, continue, break <line>, print <name>, vars or quit\n", command)
+		}
+	}
+}
+
+// printVariables prints the variables visible from environment, innermost scope first
+func (d *Debugger) printVariables(environment *Environment) {
+	seen := make(map[string]bool)
+	for env := environment; env != nil; env = env.enclosing {
+		names := make([]string, 0, len(env.values))
+		for name := range env.values {
+			if seen[name] || (env.enclosing == nil && d.predefined[name]) {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			fmt.Fprintf(d.out, "%s = %s\n", name, stringify(env.values[name]))
+		}
+	}
+}
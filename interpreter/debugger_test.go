@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDebugger_Step(t *testing.T) {
+	code := `var a = 1;
+var b = a + 1;
+print b;`
+	commands := strings.Join([]string{"step", "print a", "step", "vars", "print c", "continue"}, "\n")
+
+	i := New()
+	var out, debugOut bytes.Buffer
+	i.SetOutput(&out)
+	i.SetDebugger(NewDebugger(strings.NewReader(commands), &debugOut))
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `[line 1] (define a 1)
+(debug) [line 2] (define b (+ a 1))
+(debug) 1
+(debug) [line 3] (print b)
+(debug) a = 1
+b = 2
+(debug) Undefined variable c
+(debug) `
+	if debugOut.String() != expected {
+		t.Errorf("Expected debugger output %q, got %q", expected, debugOut.String())
+	}
+	if out.String() != "2\n" {
+		t.Errorf("Expected the program to finish, got %q", out.String())
+	}
+}
+
+func TestDebugger_Breakpoint(t *testing.T) {
+	code := `var total = 0;
+fun add(x) {
+  total = total + x;
+}
+add(1);
+add(2);`
+	commands := strings.Join([]string{"break 3", "continue", "print x", "continue", "print x", "print total", "quit"}, "\n")
+
+	i := New()
+	var debugOut bytes.Buffer
+	i.SetDebugger(NewDebugger(strings.NewReader(commands), &debugOut))
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+
+	err = i.Interpret(statements)
+	if !errors.Is(err, ErrDebuggerQuit) {
+		t.Fatalf("Expected ErrDebuggerQuit, got %v", err)
+	}
+
+	lines := strings.Split(debugOut.String(), "\n")
+	expected := []string{
+		"[line 1] (define total 0)",
+		"(debug) (debug) [line 3] (set! total (+ total x))",
+		"(debug) 1",
+		"(debug) [line 3] (set! total (+ total x))",
+		"(debug) 2",
+		"(debug) 1",
+		"(debug) ",
+	}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("Expected debugger output %q, got %q", expected, lines)
+	}
+}
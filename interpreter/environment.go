@@ -2,12 +2,26 @@ package interpreter
 
 import (
 	"fmt"
+	"github.com/ocowchun/go-lox/ast"
 	"github.com/ocowchun/go-lox/token"
+	"maps"
 )
 
 type Environment struct {
 	enclosing *Environment
 	values    map[string]any
+	// names of the values that can't be assigned, nil until a constant is defined
+	constants map[string]bool
+
+	// isCall marks the environment holding the parameters of a function call
+	isCall bool
+	// expressions deferred by `defer` statements of the call, only used when isCall is true
+	deferred []deferredExpression
+}
+
+type deferredExpression struct {
+	expression  ast.Expr
+	environment *Environment // The environment in which the expression was deferred
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
@@ -17,8 +31,46 @@ func NewEnvironment(enclosing *Environment) *Environment {
 	}
 }
 
+func newCallEnvironment(enclosing *Environment) *Environment {
+	environment := NewEnvironment(enclosing)
+	environment.isCall = true
+	return environment
+}
+
+// callEnvironment returns the environment of the innermost function call enclosing e
+func (e *Environment) callEnvironment() *Environment {
+	for env := e; env != nil; env = env.enclosing {
+		if env.isCall {
+			return env
+		}
+	}
+
+	return nil
+}
+
 func (e *Environment) Define(name string, value any) {
 	e.values[name] = value
+	// a redefinition, like in the REPL, replaces the constant with a variable
+	delete(e.constants, name)
+}
+
+// DefineConst binds name like Define, but Assign and AssignAt fail on it afterwards
+func (e *Environment) DefineConst(name string, value any) {
+	e.values[name] = value
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
+}
+
+// Snapshot returns a shallow copy of the bindings of this environment, enclosing environments are not included
+func (e *Environment) Snapshot() map[string]any {
+	return maps.Clone(e.values)
+}
+
+// Restore resets the bindings of this environment to a snapshot taken by Snapshot
+func (e *Environment) Restore(snapshot map[string]any) {
+	e.values = maps.Clone(snapshot)
 }
 
 func (e *Environment) Depth() int {
@@ -32,28 +84,46 @@ func (e *Environment) Depth() int {
 }
 
 func (e *Environment) Assign(name token.Token, value any) error {
-	if _, exists := e.values[name.Lexeme]; !exists {
-		if e.enclosing != nil {
-			return e.enclosing.Assign(name, value)
+	for env := e; env != nil; env = env.enclosing {
+		if _, exists := env.values[name.Lexeme]; !exists {
+			continue
+		}
+		if env.constants[name.Lexeme] {
+			return NewRuntimeError(name, fmt.Sprintf("Cannot assign to constant %s", name.Lexeme))
 		}
 
-		return NewRuntimeError(name, fmt.Sprintf("Undefined variable %s", name.Lexeme))
+		env.values[name.Lexeme] = value
+		return nil
 	}
 
-	e.values[name.Lexeme] = value
-	return nil
+	return e.undefinedVariableError(name)
 }
 
 func (e *Environment) Get(name token.Token) (any, error) {
-	value, exists := e.values[name.Lexeme]
-	if !exists {
-		if e.enclosing != nil {
-			return e.enclosing.Get(name)
+	for env := e; env != nil; env = env.enclosing {
+		if value, exists := env.values[name.Lexeme]; exists {
+			return value, nil
 		}
+	}
+
+	return nil, e.undefinedVariableError(name)
+}
+
+// undefinedVariableError suggests the closest name visible from e, when one is close enough
+func (e *Environment) undefinedVariableError(name token.Token) error {
+	message := fmt.Sprintf("Undefined variable %s", name.Lexeme)
 
-		return nil, NewRuntimeError(name, fmt.Sprintf("Undefined variable %s", name.Lexeme))
+	names := make([]string, 0)
+	for env := e; env != nil; env = env.enclosing {
+		for visible := range env.values {
+			names = append(names, visible)
+		}
+	}
+	if suggestion, ok := closestName(name.Lexeme, names); ok {
+		message += fmt.Sprintf("; did you mean '%s'?", suggestion)
 	}
-	return value, nil
+
+	return NewRuntimeError(name, message)
 }
 
 func (e *Environment) GetAt(name token.Token, depth int) (any, error) {
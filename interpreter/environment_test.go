@@ -0,0 +1,114 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+func TestEnvironment_SnapshotAndRestore(t *testing.T) {
+	enclosing := NewEnvironment(nil)
+	enclosing.Define("global", "global value")
+	environment := NewEnvironment(enclosing)
+	environment.Define("a", float64(1))
+
+	snapshot := environment.Snapshot()
+
+	a := token.Token{Lexeme: "a"}
+	err := environment.Assign(a, float64(2))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	environment.Define("b", float64(3))
+
+	if _, ok := snapshot["global"]; ok {
+		t.Errorf("Expected snapshot to only contain bindings of its own environment")
+	}
+	if snapshot["a"] != float64(1) {
+		t.Errorf("Expected snapshot not to change after mutation, got %v", snapshot["a"])
+	}
+
+	environment.Restore(snapshot)
+
+	value, err := environment.Get(a)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != float64(1) {
+		t.Errorf("Expected restored value 1, got %v", value)
+	}
+	if _, err = environment.Get(token.Token{Lexeme: "b"}); err == nil {
+		t.Errorf("Expected b to be undefined after restore")
+	}
+
+	environment.Define("c", float64(4))
+	if _, ok := snapshot["c"]; ok {
+		t.Errorf("Expected snapshot not to be shared with the restored environment")
+	}
+}
+
+func TestEnvironment_DefineConst(t *testing.T) {
+	enclosing := NewEnvironment(nil)
+	enclosing.DefineConst("answer", float64(42))
+	environment := NewEnvironment(enclosing)
+
+	answer := token.Token{Lexeme: "answer"}
+	value, err := environment.Get(answer)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != float64(42) {
+		t.Errorf("Expected 42, got %v", value)
+	}
+
+	for name, assign := range map[string]func() error{
+		"Assign":   func() error { return environment.Assign(answer, float64(1)) },
+		"AssignAt": func() error { return environment.AssignAt(answer, 1, float64(1)) },
+	} {
+		err = assign()
+		var runtimeError *RuntimeError
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("Expected %s to fail with a RuntimeError, got %v", name, err)
+		}
+		if runtimeError.Message != "Cannot assign to constant answer" {
+			t.Errorf("Expected the constant error from %s, got %q", name, runtimeError.Message)
+		}
+	}
+
+	value, _ = environment.Get(answer)
+	if value != float64(42) {
+		t.Errorf("Expected the constant to keep its value, got %v", value)
+	}
+}
+
+func TestEnvironment_UndefinedVariableSuggestion(t *testing.T) {
+	globals := NewEnvironment(nil)
+	globals.Define("count", float64(1))
+	environment := NewEnvironment(globals)
+	environment.Define("total", float64(2))
+
+	tests := []struct {
+		name     string
+		lexeme   string
+		expected string
+	}{
+		{"name in an enclosing scope", "cout", "Undefined variable cout; did you mean 'count'?"},
+		{"name in the current scope", "totl", "Undefined variable totl; did you mean 'total'?"},
+		{"nothing close", "x", "Undefined variable x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := environment.Get(token.Token{Lexeme: tt.lexeme})
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, runtimeError.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/ocowchun/go-lox/token"
+)
+
+// GoFunc adapts an ordinary Go function to the Callable interface, so hosts can register it with DefineGlobal
+type GoFunc struct {
+	arity int
+	fn    func(args []any) (any, error)
+}
+
+func NewGoFunc(arity int, fn func(args []any) (any, error)) Callable {
+	return &GoFunc{
+		arity: arity,
+		fn:    fn,
+	}
+}
+
+func (f *GoFunc) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	value, err := f.fn(args)
+	if err != nil {
+		var runtimeError *RuntimeError
+		if !errors.As(err, &runtimeError) {
+			err = NewRuntimeError(token.Token{Lexeme: f.String()}, err.Error())
+		}
+		return EvaluatedResult{Error: err}
+	}
+
+	return EvaluatedResult{Value: value}
+}
+
+func (f *GoFunc) Arity() int {
+	return f.arity
+}
+
+func (f *GoFunc) String() string {
+	return "<native fn>"
+}
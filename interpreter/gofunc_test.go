@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGoFunc_Call(t *testing.T) {
+	i := New()
+	i.DefineGlobal("add", NewGoFunc(2, func(args []any) (any, error) {
+		return args[0].(float64) + args[1].(float64), nil
+	}))
+
+	statements := parseCode("var sum = add(1, 2);")
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sum := getGlobal(t, i, "sum"); sum != float64(3) {
+		t.Errorf("Expected 3, got %v", sum)
+	}
+}
+
+func TestGoFunc_ErrorBecomesRuntimeError(t *testing.T) {
+	i := New()
+	i.DefineGlobal("fail", NewGoFunc(0, func(args []any) (any, error) {
+		return nil, fmt.Errorf("something went wrong")
+	}))
+
+	err := i.Interpret(parseCode("fail();"))
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "something went wrong" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestGoFunc_ThrowError(t *testing.T) {
+	i := New()
+	i.DefineGlobal("fail", NewGoFunc(0, func(args []any) (any, error) {
+		return nil, NewThrowError("boom")
+	}))
+
+	err := i.Interpret(parseCode("fail();"))
+
+	var throwError *ThrowError
+	if !errors.As(err, &throwError) {
+		t.Fatalf("Expected ThrowError, got %T", err)
+	}
+	if throwError.Value != "boom" {
+		t.Errorf("Expected the thrown value, got %v", throwError.Value)
+	}
+}
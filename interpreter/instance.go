@@ -1,7 +1,9 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
+
 	"github.com/ocowchun/go-lox/token"
 )
 
@@ -31,7 +33,25 @@ func (i *Instance) Get(name token.Token) (any, error) {
 		return method.Bind(i), nil
 	}
 
-	return nil, fmt.Errorf("undefined property '%s' in instance of class '%s'", name.Lexeme, i.class.name)
+	message := fmt.Sprintf("undefined property '%s' in instance of class '%s'", name.Lexeme, i.class.name)
+	if suggestion, ok := closestName(name.Lexeme, i.propertyNames()); ok {
+		message += fmt.Sprintf("; did you mean '%s'?", suggestion)
+	}
+	return nil, errors.New(message)
+}
+
+// propertyNames returns the names of the fields and the methods, inherited ones included
+func (i *Instance) propertyNames() []string {
+	names := make([]string, 0, len(i.fields))
+	for name := range i.fields {
+		names = append(names, name)
+	}
+	for class := i.class; class != nil; class = class.superclass {
+		for name := range class.methods {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 func (i *Instance) Set(name token.Token, value any) {
@@ -1,56 +1,192 @@
 package interpreter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/ocowchun/go-lox/ast"
 	"github.com/ocowchun/go-lox/token"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Interpreter struct {
 	environment *Environment
 	globals     *Environment
-	locals      map[ast.Expr]int
+	// declarations of the local variables used by functions nested inside their scope,
+	// keyed by the name token inside the declaring node so same-name declarations stay apart
+	captured map[*token.Token]bool
+	// where `print` writes to
+	stdout io.Writer
+	// where ReportError writes to, kept apart from stdout so diagnostics don't mix with what scripts print
+	stderr io.Writer
+	// checked at loop and call boundaries, so a running program can be stopped
+	ctx context.Context
+	// when enabled, whole-valued number literals are int64 and integer arithmetic stays exact
+	integerMode bool
+	// the total number of loop iterations allowed across all loops, zero or negative means unlimited
+	maxLoopIterations int
+	loopIterations    int
+	// the clock natives read the time from, replaceable so tests don't depend on the wall clock
+	now func() time.Time
+	// when set, it's consulted before every statement
+	debugger *Debugger
+	// lines of the executed statements, nil unless coverage is enabled
+	executedLines map[int]bool
+	// when enabled, `!` only accepts booleans instead of negating truthiness
+	strictNot bool
+	// when positive, `==` and `!=` treat numbers closer than it as equal
+	equalityEpsilon float64
 }
 
-// TODO: move builtin to a separate file
-type clockFunction struct {
+func New() *Interpreter {
+	globals := NewEnvironment(nil)
+
+	defineBuiltins(globals)
+
+	return &Interpreter{
+		globals:     globals,
+		environment: globals,
+		captured:    make(map[*token.Token]bool),
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		ctx:         context.Background(),
+		now:         time.Now,
+	}
 }
 
-func (c *clockFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
-	return EvaluatedResult{
-		Value: float64(time.Now().Unix()),
+// Reset discards everything the scripts run so far defined, so the interpreter can run an unrelated script.
+// The globals are back to the builtins, the settings like the output, the clock or integer mode are kept.
+func (interpreter *Interpreter) Reset() {
+	globals := NewEnvironment(nil)
+	defineBuiltins(globals)
+
+	interpreter.globals = globals
+	interpreter.environment = globals
+	interpreter.captured = make(map[*token.Token]bool)
+	interpreter.loopIterations = 0
+	if interpreter.executedLines != nil {
+		interpreter.executedLines = make(map[int]bool)
 	}
 }
 
-func (c *clockFunction) Arity() int {
-	return 0
+// SetClock replaces the source of the current time used by clock() and benchmark()
+func (interpreter *Interpreter) SetClock(now func() time.Time) {
+	interpreter.now = now
 }
 
-func New() *Interpreter {
-	globals := NewEnvironment(nil)
+// SetIntegerMode enables or disables exact integer arithmetic.
+// In integer mode, number literals without a fractional part evaluate to int64,
+// arithmetic between them stays int64 until a division, an overflow or a float operand converts it to float64.
+func (interpreter *Interpreter) SetIntegerMode(enabled bool) {
+	interpreter.integerMode = enabled
+}
 
-	globals.Define("clock", &clockFunction{})
+// SetMaxLoopIterations caps the total number of loop iterations across all loops,
+// zero or a negative limit means unlimited, which is the default.
+func (interpreter *Interpreter) SetMaxLoopIterations(limit int) {
+	interpreter.maxLoopIterations = limit
+	interpreter.loopIterations = 0
+}
 
-	return &Interpreter{
-		globals:     globals,
-		environment: globals,
-		locals:      make(map[ast.Expr]int),
+// SetStrictNot makes `!` a runtime error on non-boolean operands, by default `!5` is false like any truthy value
+func (interpreter *Interpreter) SetStrictNot(enabled bool) {
+	interpreter.strictNot = enabled
+}
+
+// SetEqualityEpsilon makes `==` and `!=` consider numbers closer than epsilon equal, so `0.1 + 0.2 == 0.3` is true.
+// Zero, the default, compares numbers exactly.
+func (interpreter *Interpreter) SetEqualityEpsilon(epsilon float64) {
+	interpreter.equalityEpsilon = epsilon
+}
+
+// SetOutput changes where `print` writes to, it's os.Stdout by default
+func (interpreter *Interpreter) SetOutput(w io.Writer) {
+	interpreter.stdout = w
+}
+
+// SetErrorOutput changes where ReportError writes to, it's os.Stderr by default
+func (interpreter *Interpreter) SetErrorOutput(w io.Writer) {
+	interpreter.stderr = w
+}
+
+// ReportError writes err to the error output, resolve and runtime errors are followed by their line.
+// Each error joined by errors.Join is reported on its own.
+func (interpreter *Interpreter) ReportError(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			interpreter.ReportError(e)
+		}
+		return
+	}
+
+	var runtimeError *RuntimeError
+	var resolveError *ResolveError
+	if errors.As(err, &resolveError) {
+		fmt.Fprintf(interpreter.stderr, "%s\n[line %d]\n", resolveError.Message, resolveError.Token.Line)
+	} else if errors.As(err, &runtimeError) {
+		fmt.Fprintf(interpreter.stderr, "%s\n[line %d]\n", runtimeError.Message, runtimeError.Token.Line)
+	} else {
+		fmt.Fprintln(interpreter.stderr, err)
 	}
 }
 
+// DefineGlobal binds a host value in the global environment, so scripts can use it.
+// The value can be any Lox value, including a Callable implemented in Go.
+func (interpreter *Interpreter) DefineGlobal(name string, value any) {
+	interpreter.globals.Define(name, value)
+}
+
+// GlobalNames returns the sorted names defined in the global environment, including builtins
+func (interpreter *Interpreter) GlobalNames() []string {
+	names := make([]string, 0, len(interpreter.globals.values))
+	for name := range interpreter.globals.values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
 type EvaluatedResult struct {
 	Value any
 	Error error
 }
 
+// resolve records on expr that it refers to a local declared depth scopes away
 func (interpreter *Interpreter) resolve(expr ast.Expr, depth int) {
-	interpreter.locals[expr] = depth
+	resolution := ast.Resolution{Local: true, Depth: depth}
+	switch e := expr.(type) {
+	case *ast.VariableExpression:
+		e.Resolution = resolution
+	case *ast.AssignExpression:
+		e.Resolution = resolution
+	case *ast.ThisExpression:
+		e.Resolution = resolution
+	case *ast.SuperExpression:
+		e.Resolution = resolution
+	}
+}
+
+func (interpreter *Interpreter) capture(declaration *token.Token) {
+	interpreter.captured[declaration] = true
+}
+
+// IsCaptured reports whether the local variable declared by name is used inside a function nested in its scope,
+// only those need their own storage per closure. name points to the name token of the declaring node,
+// e.g. &stmt.Name of a var statement or &function.Parameters[i] of a parameter.
+func (interpreter *Interpreter) IsCaptured(name *token.Token) bool {
+	return interpreter.captured[name]
 }
 
-func (interpreter *Interpreter) lookupVariable(name token.Token, expr ast.Expr) (any, error) {
-	if depth, ok := interpreter.locals[expr]; ok {
-		return interpreter.environment.GetAt(name, depth)
+func (interpreter *Interpreter) lookupVariable(name token.Token, resolution ast.Resolution) (any, error) {
+	if resolution.Local {
+		return interpreter.environment.GetAt(name, resolution.Depth)
 	}
 
 	return interpreter.globals.Get(name)
@@ -66,12 +202,63 @@ func (interpreter *Interpreter) Interpret(statements []ast.Stmt) error {
 	return nil
 }
 
+// InterpretContext runs statements until they finish or ctx is done
+func (interpreter *Interpreter) InterpretContext(ctx context.Context, statements []ast.Stmt) error {
+	previousCtx := interpreter.ctx
+	interpreter.ctx = ctx
+	defer func() {
+		interpreter.ctx = previousCtx
+	}()
+
+	err := interpreter.Interpret(statements)
+	if flusher, ok := interpreter.stdout.(interface{ Flush() error }); ok {
+		// keep the output produced before the program was stopped
+		flushErr := flusher.Flush()
+		if err == nil {
+			err = flushErr
+		}
+	}
+
+	return err
+}
+
+// InterpretWithTimeout runs statements and stops them with a RuntimeError once they run longer than d
+func (interpreter *Interpreter) InterpretWithTimeout(statements []ast.Stmt, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return interpreter.InterpretContext(ctx, statements)
+}
+
+// checkContext returns a RuntimeError reported at t if the program should stop running
+func (interpreter *Interpreter) checkContext(t token.Token) error {
+	err := interpreter.ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewRuntimeError(t, "execution timed out")
+	}
+	return NewRuntimeError(t, "execution cancelled")
+}
+
 type StatementResult struct {
 	Value any
 	Error error
 }
 
 func (interpreter *Interpreter) execute(statement ast.Stmt) StatementResult {
+	if interpreter.debugger != nil {
+		err := interpreter.debugger.beforeStatement(interpreter, statement)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+	}
+	if interpreter.executedLines != nil {
+		interpreter.executedLines[ast.StmtLine(statement)] = true
+	}
+
 	res := statement.Accept(interpreter).(StatementResult)
 	return res
 }
@@ -98,30 +285,203 @@ func (e *RuntimeError) Error() string {
 	return e.Message
 }
 
+// ThrowError is a runtime error raised on purpose by a native function, like a failed parse.
+// Unlike the other runtime errors it's meant to be caught, so it carries the thrown value.
+type ThrowError struct {
+	*RuntimeError
+	Value any
+}
+
+func NewThrowError(value any) *ThrowError {
+	return &ThrowError{
+		RuntimeError: NewRuntimeError(token.Token{}, stringify(value)),
+		Value:        value,
+	}
+}
+
+func (e *ThrowError) Unwrap() error {
+	return e.RuntimeError
+}
+
 func (interpreter *Interpreter) VisitWhileStatement(stmt *ast.WhileStatement) any {
 	for {
+		err := interpreter.checkContext(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
 		cond := interpreter.Evaluate(stmt.Condition)
 		if cond.Error != nil {
-			return cond.Error
+			return StatementResult{Error: cond.Error}
 		}
 
 		if !isTruthy(cond.Value) {
 			break
 		}
 
-		res := interpreter.execute(stmt.Body)
+		err = interpreter.countLoopIteration(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
+		res, done := afterLoopBody(interpreter.execute(stmt.Body))
+		if done {
+			return res
+		}
+	}
+
+	return StatementResult{}
+}
+
+func (interpreter *Interpreter) VisitForStatement(stmt *ast.ForStatement) any {
+	// the initializer gets a scope of its own
+	previousEnvironment := interpreter.environment
+	interpreter.environment = NewEnvironment(previousEnvironment)
+	defer func() {
+		interpreter.environment = previousEnvironment
+	}()
+
+	if stmt.Initializer != nil {
+		res := interpreter.execute(stmt.Initializer)
 		if res.Error != nil {
 			return res
 		}
 	}
 
+	// a closure capturing the loop variable keeps the value of its own iteration,
+	// so each iteration gets a fresh copy of the scope, but only when it can be observed
+	perIteration := false
+	if declaration, ok := stmt.Initializer.(*ast.VarStatement); ok {
+		perIteration = interpreter.IsCaptured(&declaration.Name)
+	}
+
+	for {
+		err := interpreter.checkContext(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
+		if stmt.Condition != nil {
+			cond := interpreter.Evaluate(stmt.Condition)
+			if cond.Error != nil {
+				return StatementResult{Error: cond.Error}
+			}
+			if !isTruthy(cond.Value) {
+				break
+			}
+		}
+
+		err = interpreter.countLoopIteration(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
+		res, done := afterLoopBody(interpreter.execute(stmt.Body))
+		if done {
+			return res
+		}
+
+		if perIteration {
+			next := NewEnvironment(previousEnvironment)
+			next.Restore(interpreter.environment.Snapshot())
+			interpreter.environment = next
+		}
+
+		if stmt.Increment != nil {
+			increment := interpreter.Evaluate(stmt.Increment)
+			if increment.Error != nil {
+				return StatementResult{Error: increment.Error}
+			}
+		}
+	}
+
 	return StatementResult{}
 }
 
+// loopControl is the value of a statement result cut short by `break` or `continue`
+type loopControl uint8
+
+const (
+	loopBreak loopControl = iota
+	loopContinue
+)
+
+func (interpreter *Interpreter) VisitBreakStatement(stmt *ast.BreakStatement) any {
+	return StatementResult{Value: loopBreak}
+}
+
+func (interpreter *Interpreter) VisitContinueStatement(stmt *ast.ContinueStatement) any {
+	return StatementResult{Value: loopContinue}
+}
+
+// afterLoopBody reports whether the loop that ran its body with res must stop, along with the result of the loop.
+// `continue` only ends the iteration, an error or a return stops the loop and reaches the enclosing function.
+func afterLoopBody(res StatementResult) (StatementResult, bool) {
+	if res.Error != nil {
+		return res, true
+	}
+
+	switch res.Value.(type) {
+	case ReturnValue:
+		return res, true
+	case loopControl:
+		return StatementResult{}, res.Value == loopBreak
+	}
+	return StatementResult{}, false
+}
+
+func (interpreter *Interpreter) VisitRepeatStatement(stmt *ast.RepeatStatement) any {
+	count := interpreter.Evaluate(stmt.Count)
+	if count.Error != nil {
+		return StatementResult{Error: count.Error}
+	}
+
+	times, ok := integerToFloat(count.Value).(float64)
+	if !ok || times < 0 || times != math.Trunc(times) {
+		err := NewRuntimeError(
+			stmt.Keyword,
+			fmt.Sprintf("repeat count must be a non-negative integer, got %s", stringify(count.Value)),
+		)
+		return StatementResult{Error: err}
+	}
+
+	for i := float64(0); i < times; i++ {
+		err := interpreter.checkContext(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
+		err = interpreter.countLoopIteration(stmt.Keyword)
+		if err != nil {
+			return StatementResult{Error: err}
+		}
+
+		res, done := afterLoopBody(interpreter.execute(stmt.Body))
+		if done {
+			return res
+		}
+	}
+
+	return StatementResult{}
+}
+
+// countLoopIteration enforces the limit set by SetMaxLoopIterations
+func (interpreter *Interpreter) countLoopIteration(keyword token.Token) error {
+	if interpreter.maxLoopIterations <= 0 {
+		return nil
+	}
+
+	interpreter.loopIterations++
+	if interpreter.loopIterations > interpreter.maxLoopIterations {
+		return NewRuntimeError(keyword, "loop iteration limit exceeded")
+	}
+	return nil
+}
+
 func (interpreter *Interpreter) VisitIfStatement(stmt *ast.IfStatement) any {
 	cond := interpreter.Evaluate(stmt.Condition)
 	if cond.Error != nil {
-		return cond.Error
+		return StatementResult{Error: cond.Error}
 	}
 
 	if isTruthy(cond.Value) {
@@ -137,7 +497,7 @@ func (interpreter *Interpreter) VisitVarStatement(stmt *ast.VarStatement) any {
 	if stmt.Initializer != nil {
 		initResult := interpreter.Evaluate(stmt.Initializer)
 		if initResult.Error != nil {
-			return initResult.Error
+			return StatementResult{Error: initResult.Error}
 		}
 		interpreter.environment.Define(stmt.Name.Lexeme, initResult.Value)
 	} else {
@@ -166,7 +526,9 @@ func (interpreter *Interpreter) executeBlockStatement(stmt *ast.BlockStatement,
 		res := interpreter.execute(statement)
 		if res.Error != nil {
 			return res
-		} else if _, ok := res.Value.(ReturnValue); ok {
+		}
+		switch res.Value.(type) {
+		case ReturnValue, loopControl:
 			return res
 		}
 	}
@@ -187,7 +549,7 @@ func (interpreter *Interpreter) VisitClassStatement(stmt *ast.ClassStatement) an
 			return StatementResult{
 				Error: NewRuntimeError(
 					stmt.Superclass.Name,
-					fmt.Sprintf("Superclass must be a class, got %T", res.Value),
+					fmt.Sprintf("Superclass must be a class, got %s", TypeName(res.Value)),
 				),
 			}
 		}
@@ -195,22 +557,24 @@ func (interpreter *Interpreter) VisitClassStatement(stmt *ast.ClassStatement) an
 
 	interpreter.environment.Define(stmt.Name.Lexeme, nil)
 
+	methodsEnvironment := interpreter.environment
 	if stmt.Superclass != nil {
-		interpreter.environment = NewEnvironment(interpreter.environment)
-		interpreter.environment.Define("super", superclass)
+		// methods close over an environment binding `super`, the class itself is defined outside of it
+		methodsEnvironment = NewEnvironment(interpreter.environment)
+		methodsEnvironment.Define("super", superclass)
 	}
 
 	methods := make(map[string]*Function)
 	for _, methodStmt := range stmt.Methods {
-		method := NewFunction(methodStmt, interpreter.environment, methodStmt.Name.Lexeme == "init")
+		method := NewFunction(methodStmt, methodsEnvironment, methodStmt.Name.Lexeme == "init")
 		methods[methodStmt.Name.Lexeme] = method
 	}
 
 	class := NewClass(stmt.Name.Lexeme, superclass, methods)
-	if stmt.Superclass != nil {
-		interpreter.environment = interpreter.environment.enclosing
+	class.staticMethods = make(map[string]*Function)
+	for _, methodStmt := range stmt.StaticMethods {
+		class.staticMethods[methodStmt.Name.Lexeme] = NewFunction(methodStmt, methodsEnvironment, false)
 	}
-
 	err := interpreter.environment.Assign(stmt.Name, class)
 	if err != nil {
 		return StatementResult{Error: err}
@@ -233,7 +597,7 @@ func NewFunction(declaration *ast.FunctionStatement, closure *Environment, isIni
 }
 
 func (f *Function) Call(interpreter *Interpreter, args []any) EvaluatedResult {
-	environment := NewEnvironment(f.closure)
+	environment := newCallEnvironment(f.closure)
 
 	if len(args) != f.Arity() {
 		return EvaluatedResult{
@@ -249,10 +613,12 @@ func (f *Function) Call(interpreter *Interpreter, args []any) EvaluatedResult {
 	}
 
 	// because function body is BlockStatement, we need to create a new environment
-	environment = NewEnvironment(environment)
-	res := interpreter.executeBlockStatement(f.declaration.Body, environment)
+	res := interpreter.executeBlockStatement(f.declaration.Body, NewEnvironment(environment))
+	err := interpreter.runDeferred(environment)
 	if res.Error != nil {
 		return EvaluatedResult{Error: res.Error}
+	} else if err != nil {
+		return EvaluatedResult{Error: err}
 	}
 
 	if f.isInitializer {
@@ -281,9 +647,17 @@ func (f *Function) Arity() int {
 	return len(f.declaration.Parameters)
 }
 
+// String shows the signature of the function, like <fn add(a, b)>
 func (f *Function) String() string {
-	printer := ast.NewPrinter()
-	return printer.PrintStatement(f.declaration)
+	return fmt.Sprintf("<fn %s(%s)>", f.declaration.Name.Lexeme, parameterList(f.declaration.Parameters))
+}
+
+func parameterList(parameters []token.Token) string {
+	names := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		names = append(names, parameter.Lexeme)
+	}
+	return strings.Join(names, ", ")
 }
 
 func (f *Function) Bind(instance *Instance) *Function {
@@ -304,16 +678,58 @@ func (interpreter *Interpreter) VisitFunctionStatement(stmt *ast.FunctionStateme
 
 func (interpreter *Interpreter) VisitExpressionStatement(stmt *ast.ExpressionStatement) any {
 	result := interpreter.Evaluate(stmt.Expression)
+	// the value is dropped on purpose, even a ReturnValue must not make the enclosing block return
 	return StatementResult{
 		Error: result.Error,
 	}
 }
 
+func (interpreter *Interpreter) VisitDeferStatement(stmt *ast.DeferStatement) any {
+	callEnvironment := interpreter.environment.callEnvironment()
+	if callEnvironment == nil {
+		return StatementResult{Error: NewRuntimeError(stmt.Keyword, "can't defer outside of a function")}
+	}
+
+	callEnvironment.deferred = append(callEnvironment.deferred, deferredExpression{
+		expression:  stmt.Expression,
+		environment: interpreter.environment,
+	})
+
+	return StatementResult{}
+}
+
+// runDeferred evaluates the deferred expressions of a call in LIFO order,
+// all of them run even if one fails, and the first error is returned.
+func (interpreter *Interpreter) runDeferred(callEnvironment *Environment) error {
+	previousEnvironment := interpreter.environment
+	defer func() {
+		interpreter.environment = previousEnvironment
+	}()
+
+	var firstErr error
+	for i := len(callEnvironment.deferred) - 1; i >= 0; i-- {
+		deferred := callEnvironment.deferred[i]
+		interpreter.environment = deferred.environment
+		res := interpreter.Evaluate(deferred.expression)
+		if res.Error != nil && firstErr == nil {
+			firstErr = res.Error
+		}
+	}
+	callEnvironment.deferred = nil
+
+	return firstErr
+}
+
 type ReturnValue struct {
 	Value any
 }
 
 func (interpreter *Interpreter) VisitReturnStatement(stmt *ast.ReturnStatement) any {
+	if stmt.Value == nil {
+		// a bare `return;`
+		return StatementResult{Value: ReturnValue{Value: nil}}
+	}
+
 	result := interpreter.Evaluate(stmt.Value)
 
 	return StatementResult{
@@ -323,20 +739,66 @@ func (interpreter *Interpreter) VisitReturnStatement(stmt *ast.ReturnStatement)
 }
 
 func (interpreter *Interpreter) VisitPrintStatement(stmt *ast.PrintStatement) any {
-	result := interpreter.Evaluate(stmt.Expression)
-	if result.Error != nil {
-		return StatementResult{Error: result.Error}
+	expressions := []ast.Expr{stmt.Expression}
+	if comma, ok := stmt.Expression.(*ast.CommaExpression); ok {
+		expressions = comma.Expressions
 	}
 
-	if result.Value != nil {
-		fmt.Println(result.Value)
-	} else {
-		fmt.Println("nil")
+	values := make([]string, len(expressions))
+	for i, expression := range expressions {
+		result := interpreter.Evaluate(expression)
+		if result.Error != nil {
+			return StatementResult{Error: result.Error}
+		}
+		values[i] = stringify(result.Value)
+	}
+
+	_, err := fmt.Fprintln(interpreter.stdout, strings.Join(values, " "))
+	if err != nil {
+		return StatementResult{Error: err}
 	}
 
 	return StatementResult{}
 }
 
+// stringify is the single place turning a Lox value into its display text,
+// it's used by `print`, `str()` and the display of values nested in lists and maps.
+func stringify(value any) string {
+	return stringifyVisiting(value, nil)
+}
+
+// stringifyVisiting is stringify for the elements of lists and maps, visiting holds the containers being printed
+// so a container reached again through its own elements prints as a marker instead of recursing forever
+func stringifyVisiting(value any, visiting map[any]bool) string {
+	switch v := value.(type) {
+	case *List:
+		return v.format(visiting)
+	case *Map:
+		return v.format(visiting)
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case float64:
+		// Go would print NaN and +Inf
+		switch {
+		case math.IsNaN(v):
+			return "nan"
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		}
+		return ast.FormatNumber(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 func (interpreter *Interpreter) VisitLogicalExpression(expr *ast.LogicalExpression) any {
 	left := interpreter.Evaluate(expr.Left)
 	if left.Error != nil {
@@ -357,7 +819,7 @@ func (interpreter *Interpreter) VisitLogicalExpression(expr *ast.LogicalExpressi
 }
 
 func (interpreter *Interpreter) VisitVariableExpression(expr *ast.VariableExpression) any {
-	val, err := interpreter.lookupVariable(expr.Name, expr)
+	val, err := interpreter.lookupVariable(expr.Name, expr.Resolution)
 	return EvaluatedResult{
 		Value: val,
 		Error: err,
@@ -375,6 +837,21 @@ func (interpreter *Interpreter) VisitBinaryExpression(expr *ast.BinaryExpression
 		return EvaluatedResult{Error: right.Error}
 	}
 
+	if instance, ok := left.Value.(*Instance); ok {
+		if res, ok := interpreter.callOperatorMethod(expr.Operator, instance, right.Value); ok {
+			return res
+		}
+	}
+
+	if leftInt, ok := left.Value.(int64); ok {
+		if rightInt, ok := right.Value.(int64); ok {
+			if res, ok := evaluateIntegerBinary(expr.Operator, leftInt, rightInt); ok {
+				return res
+			}
+		}
+	}
+	left.Value, right.Value = integerToFloat(left.Value), integerToFloat(right.Value)
+
 	switch expr.Operator.Type {
 	case token.TokenTypePlus:
 		if leftValue, ok := left.Value.(float64); ok {
@@ -387,6 +864,16 @@ func (interpreter *Interpreter) VisitBinaryExpression(expr *ast.BinaryExpression
 			}
 		}
 
+		_, leftIsString := left.Value.(string)
+		_, rightIsString := right.Value.(string)
+		if leftIsString || rightIsString {
+			// mixing a string with another type is usually a missing conversion
+			return EvaluatedResult{Error: NewRuntimeError(
+				expr.Operator,
+				fmt.Sprintf("cannot add %s and %s; use str() to concatenate", TypeName(left.Value), TypeName(right.Value)),
+			)}
+		}
+
 		runtimeErr := NewRuntimeError(
 			expr.Operator,
 			fmt.Sprintf("expected numbers/strings for addition, got %T and %T", left.Value, right.Value),
@@ -458,7 +945,13 @@ func (interpreter *Interpreter) VisitBinaryExpression(expr *ast.BinaryExpression
 				return EvaluatedResult{Value: leftValue >= rightValue}
 			}
 		}
-		return EvaluatedResult{Error: fmt.Errorf("expected numbers for greater than or equal comparison, got %T and %T", left.Value, right.Value)}
+
+		runtimeErr := NewRuntimeError(
+			expr.Operator,
+			fmt.Sprintf("expected numbers for greater than or equal comparison, got %T and %T", left.Value, right.Value),
+		)
+		return EvaluatedResult{Error: runtimeErr}
+
 	case token.TokenTypeLess:
 		if leftValue, ok := left.Value.(float64); ok {
 			if rightValue, ok := right.Value.(float64); ok {
@@ -486,10 +979,10 @@ func (interpreter *Interpreter) VisitBinaryExpression(expr *ast.BinaryExpression
 		return EvaluatedResult{Error: runtimeErr}
 
 	case token.TokenTypeEqualEqual:
-		return EvaluatedResult{Value: isEqual(left.Value, right.Value)}
+		return EvaluatedResult{Value: interpreter.equals(left.Value, right.Value)}
 
 	case token.TokenTypeBangEqual:
-		return EvaluatedResult{Value: isEqual(left.Value, right.Value)}
+		return EvaluatedResult{Value: !interpreter.equals(left.Value, right.Value)}
 
 	default:
 		runtimeErr := NewRuntimeError(
@@ -500,11 +993,97 @@ func (interpreter *Interpreter) VisitBinaryExpression(expr *ast.BinaryExpression
 	}
 }
 
+// operatorMethods are the methods a class defines to overload a binary operator
+var operatorMethods = map[token.TokenType]string{
+	token.TokenTypePlus:       "add",
+	token.TokenTypeMinus:      "sub",
+	token.TokenTypeStar:       "mul",
+	token.TokenTypeEqualEqual: "equals",
+	token.TokenTypeBangEqual:  "equals",
+}
+
+// callOperatorMethod calls the method overloading operator on instance with other as its argument,
+// it returns false when the class doesn't overload the operator.
+// `!=` is the negation of the `equals` overload.
+func (interpreter *Interpreter) callOperatorMethod(operator token.Token, instance *Instance, other any) (EvaluatedResult, bool) {
+	name, ok := operatorMethods[operator.Type]
+	if !ok {
+		return EvaluatedResult{}, false
+	}
+
+	method := instance.class.FindMethod(name)
+	if method == nil {
+		return EvaluatedResult{}, false
+	}
+
+	res := method.Bind(instance).Call(interpreter, []any{other})
+	if res.Error == nil && operator.Type == token.TokenTypeBangEqual {
+		res.Value = !isTruthy(res.Value)
+	}
+	return res, true
+}
+
+// evaluateIntegerBinary evaluates integer mode arithmetic exactly,
+// it returns false when the result has to be computed with floats instead.
+func evaluateIntegerBinary(operator token.Token, left int64, right int64) (EvaluatedResult, bool) {
+	switch operator.Type {
+	case token.TokenTypePlus:
+		sum := left + right
+		if (sum > left) != (right > 0) {
+			return EvaluatedResult{}, false
+		}
+		return EvaluatedResult{Value: sum}, true
+	case token.TokenTypeMinus:
+		difference := left - right
+		if (difference < left) != (right > 0) {
+			return EvaluatedResult{}, false
+		}
+		return EvaluatedResult{Value: difference}, true
+	case token.TokenTypeStar:
+		product := left * right
+		if left != 0 && (product/left != right || (left == -1 && right == math.MinInt64)) {
+			return EvaluatedResult{}, false
+		}
+		return EvaluatedResult{Value: product}, true
+	case token.TokenTypeGreater:
+		return EvaluatedResult{Value: left > right}, true
+	case token.TokenTypeGreaterEqual:
+		return EvaluatedResult{Value: left >= right}, true
+	case token.TokenTypeLess:
+		return EvaluatedResult{Value: left < right}, true
+	case token.TokenTypeLessEqual:
+		return EvaluatedResult{Value: left <= right}, true
+	case token.TokenTypeEqualEqual:
+		// converting to floats first would make distinct large integers equal
+		return EvaluatedResult{Value: left == right}, true
+	case token.TokenTypeBangEqual:
+		return EvaluatedResult{Value: left != right}, true
+	default:
+		// division can produce a fraction, so it always falls back to floats
+		return EvaluatedResult{}, false
+	}
+}
+
+func integerToFloat(value any) any {
+	if integer, ok := value.(int64); ok {
+		return float64(integer)
+	}
+
+	return value
+}
+
 func (interpreter *Interpreter) VisitGroupingExpression(expr *ast.GroupingExpression) any {
 	return interpreter.Evaluate(expr.Expression)
 }
 
 func (interpreter *Interpreter) VisitLiteralExpression(expr *ast.LiteralExpression) any {
+	if interpreter.integerMode && expr.Token.Type == token.TokenTypeNumber {
+		// parse the lexeme again, the float64 literal might have lost precision already
+		if integer, err := strconv.ParseInt(expr.Token.Lexeme, 10, 64); err == nil {
+			return EvaluatedResult{Value: integer}
+		}
+	}
+
 	return EvaluatedResult{Value: expr.Value}
 }
 
@@ -518,6 +1097,10 @@ func (interpreter *Interpreter) VisitUnaryExpression(expr *ast.UnaryExpression)
 	case token.TokenTypeMinus:
 		if value, ok := right.Value.(float64); ok {
 			return EvaluatedResult{Value: -value}
+		} else if value, ok := right.Value.(int64); ok && value != math.MinInt64 {
+			return EvaluatedResult{Value: -value}
+		} else if ok {
+			return EvaluatedResult{Value: -float64(value)}
 		} else {
 			runtimeErr := NewRuntimeError(
 				expr.Operator,
@@ -526,6 +1109,13 @@ func (interpreter *Interpreter) VisitUnaryExpression(expr *ast.UnaryExpression)
 			return EvaluatedResult{Error: runtimeErr}
 		}
 	case token.TokenTypeBang:
+		if _, ok := right.Value.(bool); !ok && interpreter.strictNot {
+			runtimeErr := NewRuntimeError(
+				expr.Operator,
+				fmt.Sprintf("expected a boolean for `!`, got %s", TypeName(right.Value)),
+			)
+			return EvaluatedResult{Error: runtimeErr}
+		}
 		return EvaluatedResult{Value: !isTruthy(right.Value)}
 
 	default:
@@ -537,6 +1127,20 @@ func (interpreter *Interpreter) VisitUnaryExpression(expr *ast.UnaryExpression)
 	}
 }
 
+// equals is isEqual for `==` and `!=`, honoring the tolerance set by SetEqualityEpsilon
+func (interpreter *Interpreter) equals(left any, right any) bool {
+	if interpreter.equalityEpsilon > 0 {
+		if leftFloat, ok := left.(float64); ok {
+			if rightFloat, ok := right.(float64); ok {
+				// the exact check keeps infinities equal to themselves, their difference is NaN
+				return leftFloat == rightFloat || math.Abs(leftFloat-rightFloat) < interpreter.equalityEpsilon
+			}
+		}
+	}
+
+	return isEqual(left, right)
+}
+
 func isEqual(left any, right any) bool {
 	if left == nil && right == nil {
 		return true
@@ -547,8 +1151,20 @@ func isEqual(left any, right any) bool {
 
 	if leftFloat, ok := left.(float64); ok {
 		if rightFloat, ok := right.(float64); ok {
+			// like IEEE 754, NaN is not equal to anything, itself included
 			return leftFloat == rightFloat
 		}
+		if rightInt, ok := right.(int64); ok {
+			return integerEqualsFloat(rightInt, leftFloat)
+		}
+	}
+	if leftInt, ok := left.(int64); ok {
+		if rightFloat, ok := right.(float64); ok {
+			return integerEqualsFloat(leftInt, rightFloat)
+		}
+		if rightInt, ok := right.(int64); ok {
+			return leftInt == rightInt
+		}
 	}
 
 	if leftString, ok := left.(string); ok {
@@ -562,7 +1178,18 @@ func isEqual(left any, right any) bool {
 		}
 	}
 
-	return false
+	// classes, instances and functions are compared by identity
+	return left == right
+}
+
+// integerEqualsFloat compares an integer mode number with a float exactly,
+// converting i to a float instead would make large integers equal to their float neighbours
+func integerEqualsFloat(i int64, f float64) bool {
+	// -2^63 is exactly representable, 2^63 is the first float past math.MaxInt64
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return false
+	}
+	return int64(f) == i
 }
 
 func isTruthy(val any) bool {
@@ -592,9 +1219,70 @@ func (interpreter *Interpreter) VisitCommaExpression(expr *ast.CommaExpression)
 	return res
 }
 
+// VisitConditionExpression evaluates `predicate ? consequent : alternative`, only the taken branch is evaluated
 func (interpreter *Interpreter) VisitConditionExpression(expr *ast.ConditionExpression) any {
-	// TODO
-	return nil
+	predicate := interpreter.Evaluate(expr.Predicate)
+	if predicate.Error != nil {
+		return predicate
+	}
+
+	if isTruthy(predicate.Value) {
+		return interpreter.Evaluate(expr.Consequent)
+	}
+	return interpreter.Evaluate(expr.Alternative)
+}
+
+// VisitMatchExpression evaluates the patterns in order and only the body of the first matching arm
+func (interpreter *Interpreter) VisitMatchExpression(expr *ast.MatchExpression) any {
+	subject := interpreter.Evaluate(expr.Subject)
+	if subject.Error != nil {
+		return subject
+	}
+
+	for _, arm := range expr.Arms {
+		if arm.Pattern != nil {
+			pattern := interpreter.Evaluate(arm.Pattern)
+			if pattern.Error != nil {
+				return pattern
+			}
+			if !isEqual(subject.Value, pattern.Value) {
+				continue
+			}
+		}
+
+		if res, matched := interpreter.evaluateMatchArm(arm, subject.Value); matched {
+			return res
+		}
+	}
+
+	return EvaluatedResult{Error: NewRuntimeError(
+		expr.Keyword,
+		fmt.Sprintf("no match arm for value %s", stringify(subject.Value)),
+	)}
+}
+
+// evaluateMatchArm evaluates the body of arm when its guard passes, with the binding of the arm defined
+func (interpreter *Interpreter) evaluateMatchArm(arm *ast.MatchArm, subject any) (EvaluatedResult, bool) {
+	if arm.Binding != nil {
+		previousEnvironment := interpreter.environment
+		interpreter.environment = NewEnvironment(previousEnvironment)
+		interpreter.environment.Define(arm.Binding.Name.Lexeme, subject)
+		defer func() {
+			interpreter.environment = previousEnvironment
+		}()
+	}
+
+	if arm.Guard != nil {
+		guard := interpreter.Evaluate(arm.Guard)
+		if guard.Error != nil {
+			return guard, true
+		}
+		if !isTruthy(guard.Value) {
+			return EvaluatedResult{}, false
+		}
+	}
+
+	return interpreter.Evaluate(arm.Body), true
 }
 
 func (interpreter *Interpreter) VisitAssignExpression(expr *ast.AssignExpression) any {
@@ -603,19 +1291,88 @@ func (interpreter *Interpreter) VisitAssignExpression(expr *ast.AssignExpression
 		return res
 	}
 
-	if depth, ok := interpreter.locals[expr]; ok {
-		err := interpreter.environment.AssignAt(expr.Name, depth, res.Value)
+	err := interpreter.assignVariable(expr.Name, expr.Resolution, res.Value)
+	if err != nil {
+		return EvaluatedResult{Error: err}
+	}
+
+	return res
+}
+
+func (interpreter *Interpreter) assignVariable(name token.Token, resolution ast.Resolution, value any) error {
+	if resolution.Local {
+		return interpreter.environment.AssignAt(name, resolution.Depth, value)
+	}
+
+	return interpreter.globals.Assign(name, value)
+}
+
+// VisitPostfixExpression updates the target in place, the object of a property target is evaluated only once
+func (interpreter *Interpreter) VisitPostfixExpression(expr *ast.PostfixExpression) any {
+	switch target := expr.Target.(type) {
+	case *ast.VariableExpression:
+		old, err := interpreter.lookupVariable(target.Name, target.Resolution)
 		if err != nil {
 			return EvaluatedResult{Error: err}
 		}
-	} else {
-		err := interpreter.globals.Assign(expr.Name, res.Value)
+		updated, err := interpreter.stepNumber(expr.Operator, old)
 		if err != nil {
 			return EvaluatedResult{Error: err}
 		}
+		err = interpreter.assignVariable(target.Name, target.Resolution, updated)
+		if err != nil {
+			return EvaluatedResult{Error: err}
+		}
+		return EvaluatedResult{Value: old}
+
+	case *ast.GetExpression:
+		object := interpreter.Evaluate(target.Object)
+		if object.Error != nil {
+			return object
+		}
+		instance, ok := object.Value.(*Instance)
+		if !ok {
+			err := NewRuntimeError(
+				target.Name,
+				fmt.Sprintf("only instances have properties, got %T", object.Value),
+			)
+			return EvaluatedResult{Error: err}
+		}
+		old, err := instance.Get(target.Name)
+		if err != nil {
+			return EvaluatedResult{Error: NewRuntimeError(target.Name, err.Error())}
+		}
+		updated, err := interpreter.stepNumber(expr.Operator, old)
+		if err != nil {
+			return EvaluatedResult{Error: err}
+		}
+		instance.Set(target.Name, updated)
+		return EvaluatedResult{Value: old}
+
+	default:
+		// the parser only builds postfix expressions on variables and properties
+		panic(fmt.Sprintf("unexpected postfix target %T", expr.Target))
 	}
+}
 
-	return res
+// stepNumber returns value plus or minus one for `++` or `--`, integers stay exact in integer mode until they overflow
+func (interpreter *Interpreter) stepNumber(operator token.Token, value any) (any, error) {
+	delta := int64(1)
+	if operator.Type == token.TokenTypeMinusMinus {
+		delta = -1
+	}
+
+	switch v := value.(type) {
+	case int64:
+		if stepped := v + delta; (stepped > v) == (delta > 0) {
+			return stepped, nil
+		}
+		return float64(v) + float64(delta), nil
+	case float64:
+		return v + float64(delta), nil
+	default:
+		return nil, NewRuntimeError(operator, fmt.Sprintf("expected a number for `%s`, got %s", operator.Lexeme, TypeName(value)))
+	}
 }
 
 func (interpreter *Interpreter) VisitCallExpression(expr *ast.CallExpression) any {
@@ -624,6 +1381,11 @@ func (interpreter *Interpreter) VisitCallExpression(expr *ast.CallExpression) an
 		return evaluatedResult
 	}
 
+	err := interpreter.checkContext(expr.Paren)
+	if err != nil {
+		return EvaluatedResult{Error: err}
+	}
+
 	var function Callable
 	if callable, ok := evaluatedResult.Value.(Callable); ok {
 		function = callable
@@ -635,12 +1397,21 @@ func (interpreter *Interpreter) VisitCallExpression(expr *ast.CallExpression) an
 		return EvaluatedResult{Error: runtimeErr}
 	}
 
-	if len(expr.Arguments) != function.Arity() {
-		runtimeErr := NewRuntimeError(
-			expr.Paren,
-			fmt.Sprintf("expected %d arguments but got %d", function.Arity(), len(expr.Arguments)),
-		)
-		return EvaluatedResult{Error: runtimeErr}
+	if optional, ok := function.(optionalArguments); ok {
+		if len(expr.Arguments) < function.Arity() || len(expr.Arguments) > optional.MaxArity() {
+			runtimeErr := NewRuntimeError(
+				expr.Paren,
+				fmt.Sprintf("expected %d to %d arguments but got %d", function.Arity(), optional.MaxArity(), len(expr.Arguments)),
+			)
+			return EvaluatedResult{Error: runtimeErr}
+		}
+	} else if len(expr.Arguments) != function.Arity() {
+		message := fmt.Sprintf("expected %d arguments but got %d", function.Arity(), len(expr.Arguments))
+		if class, ok := function.(*Class); ok {
+			// the arity is the one of init, which the user doesn't call by name
+			message = fmt.Sprintf("expected %d arguments to construct '%s' but got %d", function.Arity(), class.name, len(expr.Arguments))
+		}
+		return EvaluatedResult{Error: NewRuntimeError(expr.Paren, message)}
 	}
 
 	args := make([]any, 0, len(expr.Arguments))
@@ -652,7 +1423,14 @@ func (interpreter *Interpreter) VisitCallExpression(expr *ast.CallExpression) an
 		args = append(args, evaluatedResult.Value)
 	}
 
-	return function.Call(interpreter, args)
+	result := function.Call(interpreter, args)
+	var throwError *ThrowError
+	if errors.As(result.Error, &throwError) && throwError.Token.Line == 0 {
+		// natives don't know where they are called from, blame the call site
+		throwError.Token = expr.Paren
+	}
+
+	return result
 }
 
 // Create an AnonymousFunction in case later chapters want to make some adjustments in the Function type
@@ -670,7 +1448,7 @@ func NewAnonymousFunction(expression *ast.FunctionExpression, closure *Environme
 }
 
 func (f *AnonymousFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
-	environment := NewEnvironment(f.closure)
+	environment := newCallEnvironment(f.closure)
 
 	if len(args) != f.Arity() {
 		return EvaluatedResult{
@@ -685,9 +1463,13 @@ func (f *AnonymousFunction) Call(interpreter *Interpreter, args []any) Evaluated
 		environment.Define(param.Lexeme, args[i])
 	}
 
-	res := interpreter.executeBlockStatement(f.expression.Body, environment)
+	// same as Function, the body is a BlockStatement with its own scope in the resolver
+	res := interpreter.executeBlockStatement(f.expression.Body, NewEnvironment(environment))
+	err := interpreter.runDeferred(environment)
 	if res.Error != nil {
 		return EvaluatedResult{Error: res.Error}
+	} else if err != nil {
+		return EvaluatedResult{Error: err}
 	}
 
 	if returnValue, ok := res.Value.(ReturnValue); ok {
@@ -707,9 +1489,9 @@ func (f *AnonymousFunction) Arity() int {
 	return len(f.expression.Parameters)
 }
 
+// String shows the signature of the function, like <fn(a, b)>
 func (f *AnonymousFunction) String() string {
-	printer := ast.NewPrinter()
-	return printer.PrintExpression(f.expression)
+	return fmt.Sprintf("<fn(%s)>", parameterList(f.expression.Parameters))
 }
 
 func (interpreter *Interpreter) VisitFunctionExpression(expr *ast.FunctionExpression) any {
@@ -725,9 +1507,19 @@ type Callable interface {
 	Arity() int
 }
 
+// optionalArguments is implemented by natives that take between Arity() and MaxArity() arguments
+type optionalArguments interface {
+	MaxArity() int
+}
+
+// propertyGetter is implemented by the runtime types that support `.name` access
+type propertyGetter interface {
+	Get(name token.Token) (any, error)
+}
+
 func (interpreter *Interpreter) VisitGetExpression(expr *ast.GetExpression) any {
 	object := interpreter.Evaluate(expr.Object)
-	instance, ok := object.Value.(*Instance)
+	getter, ok := object.Value.(propertyGetter)
 	if !ok {
 		err := NewRuntimeError(
 			expr.Name,
@@ -736,7 +1528,7 @@ func (interpreter *Interpreter) VisitGetExpression(expr *ast.GetExpression) any
 		return EvaluatedResult{Error: err}
 	}
 
-	val, err := instance.Get(expr.Name)
+	val, err := getter.Get(expr.Name)
 	if err != nil {
 		return EvaluatedResult{Error: NewRuntimeError(expr.Name, err.Error())}
 	}
@@ -767,7 +1559,7 @@ func (interpreter *Interpreter) VisitSetExpression(expr *ast.SetExpression) any
 }
 
 func (interpreter *Interpreter) VisitThisExpression(expr *ast.ThisExpression) any {
-	val, err := interpreter.lookupVariable(expr.Keyword, expr)
+	val, err := interpreter.lookupVariable(expr.Keyword, expr.Resolution)
 
 	if err != nil {
 		return EvaluatedResult{Error: NewRuntimeError(expr.Keyword, err.Error())}
@@ -779,7 +1571,7 @@ func (interpreter *Interpreter) VisitThisExpression(expr *ast.ThisExpression) an
 }
 
 func (interpreter *Interpreter) VisitSuperExpression(expr *ast.SuperExpression) any {
-	distance := interpreter.locals[expr]
+	distance := expr.Resolution.Depth
 	obj, err := interpreter.environment.GetAt(expr.Keyword, distance)
 	if err != nil {
 		return EvaluatedResult{Error: NewRuntimeError(expr.Keyword, err.Error())}
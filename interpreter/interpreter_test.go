@@ -0,0 +1,1793 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocowchun/go-lox/ast"
+	"github.com/ocowchun/go-lox/token"
+)
+
+func interpretTestCode(code string) (*Interpreter, error) {
+	interpreter := New()
+	resolver := NewResolver(interpreter)
+
+	statements := parseCode(code)
+	err := resolver.ResolveStatements(statements)
+	if err != nil {
+		return interpreter, err
+	}
+
+	return interpreter, interpreter.Interpret(statements)
+}
+
+func getGlobal(t *testing.T, interpreter *Interpreter, name string) any {
+	t.Helper()
+
+	value, err := interpreter.globals.Get(token.Token{Lexeme: name})
+	if err != nil {
+		t.Fatalf("Failed to get global %s: %v", name, err)
+	}
+	return value
+}
+
+func TestInterpreter_GlobalNames(t *testing.T) {
+	code := `
+var foo = 1;
+fun bar() {}
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := i.GlobalNames()
+	for _, name := range []string{"bar", "clock", "foo"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s in global names, got %v", name, names)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Expected global names to be sorted, got %v", names)
+	}
+}
+
+type logFunction struct {
+	messages []any
+}
+
+func (f *logFunction) Call(interpreter *Interpreter, args []any) EvaluatedResult {
+	f.messages = append(f.messages, args[0])
+	return EvaluatedResult{}
+}
+
+func (f *logFunction) Arity() int {
+	return 1
+}
+
+func TestInterpreter_DefineGlobal(t *testing.T) {
+	code := `
+log("hello");
+log(greeting);
+`
+	i := New()
+	log := &logFunction{}
+	i.DefineGlobal("log", log)
+	i.DefineGlobal("greeting", "hi")
+
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !slices.Equal(log.messages, []any{"hello", "hi"}) {
+		t.Errorf("Expected log to be called with hello and hi, got %v", log.messages)
+	}
+}
+
+func TestInterpreter_InterpretWithTimeout(t *testing.T) {
+	code := `
+var i = 0;
+while (true) {
+	print i;
+	i = i + 1;
+}
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	statements := parseCode(code)
+
+	start := time.Now()
+	err := i.InterpretWithTimeout(statements, 50*time.Millisecond)
+	elapsed := time.Since(start)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "execution timed out" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected program to stop shortly after the timeout, took %v", elapsed)
+	}
+	if !strings.HasPrefix(out.String(), "0\n1\n") {
+		t.Errorf("Expected output before the timeout to be kept, got %q", out.String())
+	}
+}
+
+func TestInterpreter_LoopLimitInForLoopReportsForKeyword(t *testing.T) {
+	code := `var x = 1;
+
+for (var i = 0; i < 100; i = i + 1) {
+	x = x + 1;
+}
+`
+	i := New()
+	i.SetMaxLoopIterations(5)
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = i.Interpret(statements)
+
+	// the error isn't caused by any token of the body, its location comes from the loop's `for` keyword
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Token.Lexeme != "for" || runtimeError.Token.Line != 3 {
+		t.Errorf("Expected error at the `for` on line 3, got %q at line %d", runtimeError.Token.Lexeme, runtimeError.Token.Line)
+	}
+}
+
+func TestInterpreter_TimeoutInForLoopReportsForLine(t *testing.T) {
+	code := `var x = 1;
+
+for (;;) {
+	x = x + 1;
+}
+`
+	i := New()
+	statements := parseCode(code)
+
+	err := i.InterpretWithTimeout(statements, 10*time.Millisecond)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Token.Line != 3 {
+		t.Errorf("Expected error at line 3, got %d", runtimeError.Token.Line)
+	}
+}
+
+func TestInterpreter_ComparisonErrorReportsOperatorLine(t *testing.T) {
+	code := `var a = "a";
+print a
+	>= 1;
+`
+
+	_, err := interpretTestCode(code)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Token.Lexeme != ">=" {
+		t.Errorf("Expected error at `>=`, got %s", runtimeError.Token.Lexeme)
+	}
+	if runtimeError.Token.Line != 3 {
+		t.Errorf("Expected error at line 3, got %d", runtimeError.Token.Line)
+	}
+}
+
+func TestInterpreter_IntegerMode(t *testing.T) {
+	code := `
+var sum = 9007199254740993 + 1;
+var product = 3037000499 * 3;
+var quotient = 7 / 2;
+var mixed = 1 + 0.5;
+var compared = 9007199254740993 > 9007199254740992;
+var equal = 9007199254740993 == 9007199254740992;
+var notEqual = 9007199254740993 != 9007199254740992;
+var sameHash = hash(9007199254740993) == hash(9007199254740992);
+var floatHash = hash(2) == hash(2.0);
+`
+	i := New()
+	i.SetIntegerMode(true)
+	statements := parseCode(code)
+	err := i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sum := getGlobal(t, i, "sum"); sum != int64(9007199254740994) {
+		t.Errorf("Expected exact int64 9007199254740994, got %v (%T)", sum, sum)
+	}
+	if product := getGlobal(t, i, "product"); product != int64(9111001497) {
+		t.Errorf("Expected exact int64 9111001497, got %v (%T)", product, product)
+	}
+	if quotient := getGlobal(t, i, "quotient"); quotient != 3.5 {
+		t.Errorf("Expected float64 3.5 after division, got %v (%T)", quotient, quotient)
+	}
+	if mixed := getGlobal(t, i, "mixed"); mixed != 1.5 {
+		t.Errorf("Expected float64 1.5, got %v (%T)", mixed, mixed)
+	}
+	if compared := getGlobal(t, i, "compared"); compared != true {
+		t.Errorf("Expected large integers to compare exactly, got %v", compared)
+	}
+	if equal := getGlobal(t, i, "equal"); equal != false {
+		t.Errorf("Expected distinct large integers not to be equal, got %v", equal)
+	}
+	if notEqual := getGlobal(t, i, "notEqual"); notEqual != true {
+		t.Errorf("Expected distinct large integers to be not equal, got %v", notEqual)
+	}
+	if sameHash := getGlobal(t, i, "sameHash"); sameHash != false {
+		t.Errorf("Expected distinct large integers to hash differently, got %v", sameHash)
+	}
+	if floatHash := getGlobal(t, i, "floatHash"); floatHash != true {
+		t.Errorf("Expected an integer to hash like the equal float, got %v", floatHash)
+	}
+}
+
+func TestInterpreter_IntegerModeEqualsFloats(t *testing.T) {
+	code := `
+assertEqual(1, 2 - 1.0);
+assertEqual(1.0, 2 - 1);
+var matched = match (1) { 1.0 => "one", _ => "other" };
+var large = match (9007199254740993) { 9007199254740992.0 => "float neighbour", _ => "exact" };
+var huge = match (9223372036854775807) { 9223372036854775808.0 => "rounded", _ => "exact" };
+`
+	i := New()
+	i.SetIntegerMode(true)
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{"matched": "one", "large": "exact", "huge": "exact"}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %q, got %v", name, value, actual)
+		}
+	}
+
+	err = i.Interpret(parseCode("assertEqual(1, 1.5);"))
+	if err == nil {
+		t.Errorf("Expected different numbers to fail assertEqual")
+	}
+}
+
+func TestInterpreter_IntegerModeFallsBackToFloatOnOverflow(t *testing.T) {
+	i := New()
+	i.SetIntegerMode(true)
+	err := i.Interpret(parseCode("var overflow = 9223372036854775807 + 1;"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if overflow := getGlobal(t, i, "overflow"); overflow != float64(9223372036854775807)+1 {
+		t.Errorf("Expected float64 result, got %v (%T)", overflow, overflow)
+	}
+}
+
+func TestInterpreter_NumbersAreFloatsByDefault(t *testing.T) {
+	i, err := interpretTestCode("var sum = 9007199254740993 + 1;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sum, ok := getGlobal(t, i, "sum").(float64); !ok {
+		t.Errorf("Expected float64, got %T", sum)
+	}
+}
+
+func TestInterpreter_DeferRunsInReverseOrder(t *testing.T) {
+	code := `
+fun foo(early) {
+	defer log("first deferred");
+	defer log("second deferred");
+	if (early) {
+		log("early return");
+		return 1;
+	}
+	log("body");
+	return 2;
+}
+var late = foo(false);
+var early = foo(true);
+`
+	i := New()
+	log := &logFunction{}
+	i.DefineGlobal("log", log)
+
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []any{
+		"body", "second deferred", "first deferred",
+		"early return", "second deferred", "first deferred",
+	}
+	if !slices.Equal(log.messages, expected) {
+		t.Errorf("Expected %v, got %v", expected, log.messages)
+	}
+	if late := getGlobal(t, i, "late"); late != float64(2) {
+		t.Errorf("Expected 2, got %v", late)
+	}
+	if early := getGlobal(t, i, "early"); early != float64(1) {
+		t.Errorf("Expected 1, got %v", early)
+	}
+}
+
+func TestInterpreter_PostfixIncrementAndDecrement(t *testing.T) {
+	code := `
+var count = 0;
+for (var i = 0; i < 5; i++) {
+	count++;
+}
+var down = 10;
+var before = down--;
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if count := getGlobal(t, i, "count"); count != float64(5) {
+		t.Errorf("Expected 5, got %v", count)
+	}
+	if down := getGlobal(t, i, "down"); down != float64(9) {
+		t.Errorf("Expected 9, got %v", down)
+	}
+	if before := getGlobal(t, i, "before"); before != float64(10) {
+		t.Errorf("Expected the value before decrement 10, got %v", before)
+	}
+}
+
+func TestInterpreter_PostfixOnPropertyEvaluatesObjectOnce(t *testing.T) {
+	code := `
+class Box {}
+var box = Box();
+box.x = 1;
+var calls = 0;
+fun f() {
+	calls = calls + 1;
+	return box;
+}
+var before = f().x++;
+var after = box.x;
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]any{"calls": float64(1), "before": float64(1), "after": float64(2)}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, actual)
+		}
+	}
+}
+
+func TestInterpreter_PostfixYieldsTheOriginalValue(t *testing.T) {
+	// adding then subtracting one would round 0.1 to 0.10000000000000009
+	code := `
+var x = 0.1;
+var before = x++;
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if before := getGlobal(t, i, "before"); before != 0.1 {
+		t.Errorf("Expected exactly 0.1, got %v", before)
+	}
+
+	_, err = interpretTestCode("var s = \"a\";\ns++;")
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "expected a number for `++`, got string" {
+		t.Errorf("Expected the non-number error, got %q", runtimeError.Message)
+	}
+}
+
+func TestInterpreter_LogicalExpressionResultValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		code     string
+		expected any
+	}{
+		{"or returns truthy left operand", "var result = 0 or 5;", float64(0)},
+		{"or returns right operand when left is nil", "var result = nil or 5;", float64(5)},
+		{"or returns right operand when left is false", `var result = false or "x";`, "x"},
+		{"and returns falsey left operand", "var result = false and 1;", false},
+		{"and returns nil left operand", "var result = nil and 1;", nil},
+		{"and returns right operand when left is truthy", "var result = 2 and 3;", float64(3)},
+		{"or short-circuits the right operand", "var result = true or undefinedVariable;", true},
+		{"and short-circuits the right operand", "var result = false and undefinedVariable;", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			i, err := interpretTestCode(testCase.code)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if result := getGlobal(t, i, "result"); result != testCase.expected {
+				t.Errorf("Expected %v (%T), got %v (%T)", testCase.expected, testCase.expected, result, result)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ReturnFromInsideLoop(t *testing.T) {
+	code := `
+fun firstMultipleOf(n) {
+	var i = 1;
+	while (true) {
+		if (i * n > 10) {
+			return i * n;
+		}
+		i = i + 1;
+	}
+}
+fun countTo(n) {
+	for (var i = 0; ; i = i + 1) {
+		if (i == n) return i;
+	}
+}
+var multiple = firstMultipleOf(3);
+var counted = countTo(4);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if multiple := getGlobal(t, i, "multiple"); multiple != float64(12) {
+		t.Errorf("Expected 12, got %v", multiple)
+	}
+	if counted := getGlobal(t, i, "counted"); counted != float64(4) {
+		t.Errorf("Expected 4, got %v", counted)
+	}
+}
+
+func TestInterpreter_ConditionErrorsAreReturned(t *testing.T) {
+	testCases := []struct {
+		name string
+		code string
+	}{
+		{"while condition", `while (1 + true) { print 1; }`},
+		{"if condition", `if (1 + true) { print 1; }`},
+		{"var initializer", `var a = 1 + true;`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := interpretTestCode(testCase.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Token.Lexeme != "+" {
+				t.Errorf("Expected error at `+`, got %s", runtimeError.Token.Lexeme)
+			}
+		})
+	}
+}
+
+func TestInterpreter_SuperclassMustBeAClass(t *testing.T) {
+	testCases := []struct {
+		name       string
+		superclass string
+		expected   string
+	}{
+		{"number", "1", "Superclass must be a class, got number"},
+		{"instance", "Base()", "Superclass must be a class, got instance"},
+		{"function", "fun () {}", "Superclass must be a class, got function"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			code := fmt.Sprintf("class Base {}\nvar NotAClass = %s;\nclass Foo < NotAClass {}\n", testCase.superclass)
+
+			i, err := interpretTestCode(code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != testCase.expected {
+				t.Errorf("Expected %q, got %q", testCase.expected, runtimeError.Message)
+			}
+			if runtimeError.Token.Line != 3 {
+				t.Errorf("Expected error at line 3, got %d", runtimeError.Token.Line)
+			}
+			if i.environment != i.globals {
+				t.Errorf("Expected the environment to be restored after the error")
+			}
+		})
+	}
+}
+
+func TestInterpreter_ImmediatelyInvokedFunctionExpression(t *testing.T) {
+	code := `
+var doubled = (fun (x) { return x * 2; })(21);
+var counter = fun () {
+	var count = 0;
+	return fun () {
+		count = count + 1;
+		return count;
+	};
+}();
+counter();
+var counted = counter();
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if doubled := getGlobal(t, i, "doubled"); doubled != float64(42) {
+		t.Errorf("Expected 42, got %v", doubled)
+	}
+	if counted := getGlobal(t, i, "counted"); counted != float64(2) {
+		t.Errorf("Expected 2, got %v", counted)
+	}
+}
+
+func TestInterpreter_MaxLoopIterations(t *testing.T) {
+	code := `
+var count = 0;
+while (count < 3) {
+	count = count + 1;
+}
+while (true) {
+	count = count + 1;
+}
+`
+	i := New()
+	i.SetMaxLoopIterations(10)
+
+	err := i.Interpret(parseCode(code))
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "loop iteration limit exceeded" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+	if runtimeError.Token.Line != 6 {
+		t.Errorf("Expected error at line 6, got %d", runtimeError.Token.Line)
+	}
+	if count := getGlobal(t, i, "count"); count != float64(10) {
+		t.Errorf("Expected the limit to count iterations across loops, got %v", count)
+	}
+}
+
+func TestInterpreter_OperatorOverloading(t *testing.T) {
+	code := `
+class Vector {
+	init(x, y) {
+		this.x = x;
+		this.y = y;
+	}
+
+	add(other) {
+		return Vector(this.x + other.x, this.y + other.y);
+	}
+
+	sub(other) {
+		return Vector(this.x - other.x, this.y - other.y);
+	}
+
+	mul(factor) {
+		return Vector(this.x * factor, this.y * factor);
+	}
+
+	equals(other) {
+		return this.x == other.x and this.y == other.y;
+	}
+}
+
+var v1 = Vector(1, 2);
+var v2 = Vector(3, 4);
+var sum = v1 + v2;
+var difference = v2 - v1;
+var scaled = v1 * 3;
+var same = v1 + v2 == Vector(4, 6);
+var different = v1 == v2;
+var notSame = v1 + v2 != Vector(4, 6);
+var notDifferent = v1 != v2;
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		x    float64
+		y    float64
+	}{
+		{"sum", 4, 6},
+		{"difference", 2, 2},
+		{"scaled", 3, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance, ok := getGlobal(t, i, tt.name).(*Instance)
+			if !ok {
+				t.Fatalf("Expected *Instance, got %T", getGlobal(t, i, tt.name))
+			}
+			if instance.fields["x"] != tt.x || instance.fields["y"] != tt.y {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.x, tt.y, instance.fields["x"], instance.fields["y"])
+			}
+		})
+	}
+
+	if same := getGlobal(t, i, "same"); same != true {
+		t.Errorf("Expected equals to be used for ==, got %v", same)
+	}
+	if different := getGlobal(t, i, "different"); different != false {
+		t.Errorf("Expected equals to be used for ==, got %v", different)
+	}
+	if notSame := getGlobal(t, i, "notSame"); notSame != false {
+		t.Errorf("Expected equals to be used for !=, got %v", notSame)
+	}
+	if notDifferent := getGlobal(t, i, "notDifferent"); notDifferent != true {
+		t.Errorf("Expected equals to be used for !=, got %v", notDifferent)
+	}
+}
+
+func TestInterpreter_OperatorWithoutOverload(t *testing.T) {
+	code := `
+class Foo {}
+var foo = Foo();
+var same = foo == foo;
+foo + 1;
+`
+
+	_, err := interpretTestCode(code)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Token.Lexeme != "+" {
+		t.Errorf("Expected the error at +, got %v", runtimeError.Token.Lexeme)
+	}
+}
+
+func TestInterpreter_NaNAndInfinity(t *testing.T) {
+	code := `
+var nanEqual = nan == nan;
+var infEqual = inf == inf;
+print nan;
+print inf;
+print -inf;
+print inf * 0;
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("nan", math.NaN())
+	i.DefineGlobal("inf", math.Inf(1))
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if nanEqual := getGlobal(t, i, "nanEqual"); nanEqual != false {
+		t.Errorf("Expected nan == nan to be false, got %v", nanEqual)
+	}
+	if infEqual := getGlobal(t, i, "infEqual"); infEqual != true {
+		t.Errorf("Expected inf == inf to be true, got %v", infEqual)
+	}
+	if out.String() != "nan\ninf\n-inf\nnan\n" {
+		t.Errorf("Expected nan/inf output, got %q", out.String())
+	}
+}
+
+func TestInterpreter_AssignmentInCommaExpression(t *testing.T) {
+	code := `
+var a;
+var b = (a = 1, 2);
+var c;
+{
+	var local;
+	c = (local = 3, local + 1);
+}
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected any
+	}{
+		{"a", float64(1)},
+		{"b", float64(2)},
+		{"c", float64(4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := getGlobal(t, i, tt.name); value != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestInterpreter_RepeatStatement(t *testing.T) {
+	code := `
+var count = 0;
+var n = 3;
+repeat (n) {
+	count = count + 1;
+	n = 10;
+}
+repeat (0) count = count + 100;
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if count := getGlobal(t, i, "count"); count != float64(3) {
+		t.Errorf("Expected the body to run 3 times, got %v", count)
+	}
+}
+
+func TestInterpreter_RepeatStatementInvalidCount(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"repeat (-1) {}", "repeat count must be a non-negative integer, got -1"},
+		{"repeat (1.5) {}", "repeat count must be a non-negative integer, got 1.5"},
+		{`repeat ("3") {}`, "repeat count must be a non-negative integer, got 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			_, err := interpretTestCode(tt.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, runtimeError.Message)
+			}
+		})
+	}
+}
+
+func TestInterpreter_FunctionString(t *testing.T) {
+	code := `
+fun add(a, b) {
+	return a + b;
+}
+class Foo {
+	bar() {}
+}
+print add;
+print fun (a, b) {};
+print fun () {};
+print Foo().bar;
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "<fn add(a, b)>\n<fn(a, b)>\n<fn()>\n<fn bar()>\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestInterpreter_BareReturn(t *testing.T) {
+	code := `
+var reached = false;
+fun early(flag) {
+	if (flag) {
+		return;
+	}
+	reached = true;
+	return 1;
+}
+var result = early(true);
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result := getGlobal(t, i, "result"); result != nil {
+		t.Errorf("Expected nil, got %v", result)
+	}
+	if reached := getGlobal(t, i, "reached"); reached != false {
+		t.Errorf("Expected the function to return early, got reached = %v", reached)
+	}
+}
+
+func TestInterpreter_BareReturnInInitializer(t *testing.T) {
+	code := `
+class Foo {
+	init(skip) {
+		this.ready = false;
+		if (skip) return;
+		this.ready = true;
+	}
+}
+var foo = Foo(true);
+var ready = foo.ready;
+`
+
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := getGlobal(t, i, "foo").(*Instance); !ok {
+		t.Errorf("Expected the initializer to still return the instance, got %T", getGlobal(t, i, "foo"))
+	}
+	if ready := getGlobal(t, i, "ready"); ready != false {
+		t.Errorf("Expected the initializer to return early, got ready = %v", ready)
+	}
+}
+
+func TestInterpreter_RedefineGlobalFunctionAcrossRuns(t *testing.T) {
+	i := New()
+	for _, code := range []string{"fun f() { return 1; }", "fun f() { return 2; }", "var result = f();"} {
+		statements := parseCode(code)
+		err := NewResolver(i).ResolveStatements(statements)
+		if err != nil {
+			t.Fatalf("Expected no resolve error, got %v", err)
+		}
+		err = i.Interpret(statements)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if result := getGlobal(t, i, "result"); result != float64(2) {
+		t.Errorf("Expected the redefined function to be called, got %v", result)
+	}
+}
+
+func TestInterpreter_ExpressionStatementDoesNotReturn(t *testing.T) {
+	i := New()
+	// an expression yielding the return sentinel, like a future construct could
+	i.DefineGlobal("sentinel", NewGoFunc(0, func(args []any) (any, error) {
+		return ReturnValue{Value: 1.0}, nil
+	}))
+
+	statements := parseCode(`
+fun f() {
+  sentinel();
+  return 2;
+}
+var result = f();
+`)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result := getGlobal(t, i, "result"); result != float64(2) {
+		t.Errorf("Expected the function to run to its return statement, got %v", result)
+	}
+}
+
+func TestInterpreter_AddStringAndNumber(t *testing.T) {
+	testCases := []struct {
+		code     string
+		expected string
+	}{
+		{`1 + "a";`, "cannot add number and string; use str() to concatenate"},
+		{`"a" + 1;`, "cannot add string and number; use str() to concatenate"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.code, func(t *testing.T) {
+			_, err := interpretTestCode(testCase.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != testCase.expected {
+				t.Errorf("Expected %q, got %q", testCase.expected, runtimeError.Message)
+			}
+		})
+	}
+}
+
+func TestInterpreter_StrictNot(t *testing.T) {
+	i, err := interpretTestCode("var result = !5;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result := getGlobal(t, i, "result"); result != false {
+		t.Errorf("Expected !5 to be false in lenient mode, got %v", result)
+	}
+
+	i = New()
+	i.SetStrictNot(true)
+	statements := parseCode("var ok = !false;\nvar result = !5;")
+	err = NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "expected a boolean for `!`, got number" {
+		t.Errorf("Expected strict mode error, got %q", runtimeError.Message)
+	}
+	if ok := getGlobal(t, i, "ok"); ok != true {
+		t.Errorf("Expected !false to still be true in strict mode, got %v", ok)
+	}
+}
+
+func TestInterpreter_PrintMultipleValues(t *testing.T) {
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+
+	statements := parseCode(`var a = "a";
+print 1, 2, 3;
+print a, nil, 1 + 1;
+`)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "1 2 3\na nil 2\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestInterpreter_PrintNumberFormat(t *testing.T) {
+	testCases := []struct {
+		value    float64
+		expected string
+	}{
+		{1e21, "1e+21"},
+		{-1e21, "-1e+21"},
+		{1e-10, "1e-10"},
+		{0.0001, "0.0001"},
+		{3.14, "3.14"},
+		{1e20, "100000000000000000000"},
+		{0, "0"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			if actual := stringify(testCase.value); actual != testCase.expected {
+				t.Errorf("Expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInterpreter_MatchExpression(t *testing.T) {
+	code := `
+fun describe(x) {
+	return match (x) {
+		1 => "one",
+		"two" => "two",
+		nil => "nothing",
+		_ => "other"
+	};
+}
+var one = describe(1);
+var two = describe("two");
+var nothing = describe(nil);
+var other = describe(3);
+var evaluated = 0;
+var onlyMatched = match (2) { 1 => evaluated = 1, 2 => "two", _ => evaluated = 3 };
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]any{
+		"one":         "one",
+		"two":         "two",
+		"nothing":     "nothing",
+		"other":       "other",
+		"onlyMatched": "two",
+		"evaluated":   float64(0),
+	}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, actual)
+		}
+	}
+}
+
+func TestInterpreter_MatchExpressionWithoutMatchingArm(t *testing.T) {
+	_, err := interpretTestCode(`var result = match (3) { 1 => "one", 2 => "two" };`)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "no match arm for value 3" {
+		t.Errorf("Expected the no match error, got %q", runtimeError.Message)
+	}
+}
+
+func TestInterpreter_MatchArmGuards(t *testing.T) {
+	code := `
+fun sign(x) {
+	return match (x) {
+		0 => "zero",
+		n if n > 0 => "positive",
+		n if n < 0 => "negative"
+	};
+}
+var positive = sign(3);
+var negative = sign(-2);
+var zero = sign(0);
+var n = "outer";
+var fallthrough = match (1) { n if n > 5 => "big", _ => n };
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]any{
+		"positive":    "positive",
+		"negative":    "negative",
+		"zero":        "zero",
+		"fallthrough": "outer",
+	}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, actual)
+		}
+	}
+}
+
+func TestInterpreter_CallReturnedFunction(t *testing.T) {
+	code := `
+fun makeAdder(a) {
+	return fun (b) { return a + b; };
+}
+var sum = makeAdder(3)(4);
+var add10 = makeAdder(10);
+var other = add10(5);
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sum := getGlobal(t, i, "sum"); sum != float64(7) {
+		t.Errorf("Expected makeAdder(3)(4) to be 7, got %v", sum)
+	}
+	if other := getGlobal(t, i, "other"); other != float64(15) {
+		t.Errorf("Expected each closure to keep its own captured value, got %v", other)
+	}
+}
+
+func TestInterpreter_ConstructWithWrongArity(t *testing.T) {
+	code := `
+class Point {
+	init(x, y) {
+		this.x = x;
+		this.y = y;
+	}
+}
+var p = Point(1);
+`
+	_, err := interpretTestCode(code)
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "expected 2 arguments to construct 'Point' but got 1" {
+		t.Errorf("Expected the construction arity error, got %q", runtimeError.Message)
+	}
+}
+
+func TestInterpreter_ChainedAssignment(t *testing.T) {
+	code := `
+var a;
+var b;
+var result = a = b = 5;
+fun local() {
+	var c;
+	var d;
+	c = d = "local";
+	return c + d;
+}
+var both = local();
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "result"} {
+		if value := getGlobal(t, i, name); value != float64(5) {
+			t.Errorf("Expected %s to be 5, got %v", name, value)
+		}
+	}
+	if both := getGlobal(t, i, "both"); both != "locallocal" {
+		t.Errorf("Expected both locals to be assigned, got %v", both)
+	}
+}
+
+func TestInterpreter_ChainedAssignmentThroughProperty(t *testing.T) {
+	code := `
+class Point {}
+var obj = Point();
+var a;
+var result = a = obj.x = 5;
+var x = obj.x;
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"a", "result", "x"} {
+		if value := getGlobal(t, i, name); value != float64(5) {
+			t.Errorf("Expected %s to be 5, got %v", name, value)
+		}
+	}
+}
+
+func TestInterpreter_StaticMethods(t *testing.T) {
+	code := `
+class Math {
+	class square(n) {
+		return n * n;
+	}
+}
+class MoreMath < Math {}
+var squared = Math.square(3);
+var inherited = MoreMath.square(4);
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if squared := getGlobal(t, i, "squared"); squared != float64(9) {
+		t.Errorf("Expected 9, got %v", squared)
+	}
+	if inherited := getGlobal(t, i, "inherited"); inherited != float64(16) {
+		t.Errorf("Expected static methods to be inherited, got %v", inherited)
+	}
+}
+
+func TestInterpreter_UnknownStaticMember(t *testing.T) {
+	_, err := interpretTestCode("class Math {}\nMath.cube(2);")
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+	if runtimeError.Message != "undefined static member 'cube' on class 'Math'" {
+		t.Errorf("Expected the unknown static member error, got %q", runtimeError.Message)
+	}
+}
+
+func TestInterpreter_InstanceDisplayIsConsistent(t *testing.T) {
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("listOf", NewGoFunc(1, func(args []any) (any, error) {
+		return NewList([]any{args[0]}), nil
+	}))
+
+	statements := parseCode(`
+class Foo {}
+var foo = Foo();
+print foo;
+print str(foo);
+print listOf(foo);
+print(foo);
+`)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+	err = i.Interpret(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "Foo instance\nFoo instance\n[Foo instance]\nFoo instance\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestInterpreter_MutuallyRecursiveMethods(t *testing.T) {
+	code := `
+class Parity {
+	isEven(n) {
+		if (n == 0) return true;
+		return this.isOdd(n - 1);
+	}
+
+	isOdd(n) {
+		if (n == 0) return false;
+		return this.isEven(n - 1);
+	}
+
+	twin() {
+		return Parity();
+	}
+}
+var parity = Parity();
+var even = parity.isEven(10);
+var odd = parity.isOdd(7);
+var twinIsEven = parity.twin().isEven(3);
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if even := getGlobal(t, i, "even"); even != true {
+		t.Errorf("Expected isEven(10) to be true, got %v", even)
+	}
+	if odd := getGlobal(t, i, "odd"); odd != true {
+		t.Errorf("Expected isOdd(7) to be true, got %v", odd)
+	}
+	if twinIsEven := getGlobal(t, i, "twinIsEven"); twinIsEven != false {
+		t.Errorf("Expected methods to see the fully built class, got %v", twinIsEven)
+	}
+}
+
+func TestInterpreter_Reset(t *testing.T) {
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+
+	run := func(code string) error {
+		statements := parseCode(code)
+		err := NewResolver(i).ResolveStatements(statements)
+		if err != nil {
+			return err
+		}
+		return i.Interpret(statements)
+	}
+
+	err := run(`var leaked = "first"; fun helper() { return 1; } print str(helper());`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	i.Reset()
+
+	err = run("print leaked;")
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected the globals of the first script to be gone, got %v", err)
+	}
+	if runtimeError.Message != "Undefined variable leaked" {
+		t.Errorf("Expected undefined variable error, got %q", runtimeError.Message)
+	}
+
+	err = run(`print typeof(helper);`)
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected the functions of the first script to be gone, got %v", err)
+	}
+
+	err = run(`var leaked = "second"; print leaked;`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if out.String() != "1\nsecond\n" {
+		t.Errorf("Expected the output setting to survive the reset, got %q", out.String())
+	}
+}
+
+// foldConstants returns a copy of stmt with the additions of two number literals folded,
+// every node on the way is copied, like a tree rewriting transform would
+func foldConstants(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.BlockStatement:
+		statements := make([]ast.Stmt, len(s.Statements))
+		for i, statement := range s.Statements {
+			statements[i] = foldConstants(statement)
+		}
+		return &ast.BlockStatement{Statements: statements}
+	case *ast.VarStatement:
+		copied := *s
+		copied.Initializer = foldExpression(s.Initializer)
+		return &copied
+	case *ast.ExpressionStatement:
+		return &ast.ExpressionStatement{Expression: foldExpression(s.Expression)}
+	default:
+		return stmt
+	}
+}
+
+func foldExpression(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.BinaryExpression:
+		left, right := foldExpression(e.Left), foldExpression(e.Right)
+		leftLiteral, leftOk := left.(*ast.LiteralExpression)
+		rightLiteral, rightOk := right.(*ast.LiteralExpression)
+		if leftOk && rightOk && e.Operator.Type == token.TokenTypePlus {
+			return &ast.LiteralExpression{Value: leftLiteral.Value.(float64) + rightLiteral.Value.(float64), Token: leftLiteral.Token}
+		}
+		return &ast.BinaryExpression{Left: left, Operator: e.Operator, Right: right}
+	case *ast.GroupingExpression:
+		inner := foldExpression(e.Expression)
+		if literal, ok := inner.(*ast.LiteralExpression); ok {
+			return literal
+		}
+		return &ast.GroupingExpression{Expression: inner}
+	case *ast.VariableExpression:
+		copied := *e
+		return &copied
+	case *ast.AssignExpression:
+		copied := *e
+		copied.Value = foldExpression(e.Value)
+		return &copied
+	default:
+		return expr
+	}
+}
+
+func TestInterpreter_LocalsSurviveTransformsAfterResolution(t *testing.T) {
+	code := `
+var result;
+{
+	var a = 1;
+	var b = a + (2 + 3);
+	result = b + 1 + 1;
+}
+`
+	i := New()
+	statements := parseCode(code)
+	err := NewResolver(i).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no resolve error, got %v", err)
+	}
+
+	folded := make([]ast.Stmt, len(statements))
+	for index, statement := range statements {
+		folded[index] = foldConstants(statement)
+	}
+	err = i.Interpret(folded)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result := getGlobal(t, i, "result"); result != float64(8) {
+		t.Errorf("Expected 8, got %v", result)
+	}
+}
+
+func TestInterpreter_ReturnFromIfBranches(t *testing.T) {
+	code := `
+fun sign(n) {
+	if (n < 0) {
+		return "negative";
+	} else {
+		return "positive";
+	}
+	return "unreachable";
+}
+var negative = sign(-1);
+var positive = sign(1);
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if negative := getGlobal(t, i, "negative"); negative != "negative" {
+		t.Errorf("Expected return in then branch to propagate, got %v", negative)
+	}
+	if positive := getGlobal(t, i, "positive"); positive != "positive" {
+		t.Errorf("Expected return in else branch to propagate, got %v", positive)
+	}
+}
+
+func TestInterpreter_IfConditionError(t *testing.T) {
+	_, err := interpretTestCode("fun check() {\n\tif (-\"a\") return 1;\n}\ncheck();")
+
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("Expected RuntimeError, got %T", err)
+	}
+}
+
+func TestInterpreter_ForClosuresAgainstWhile(t *testing.T) {
+	forCode := `
+var first;
+var second;
+for (var i = 0; i < 2; i = i + 1) {
+	var j = i;
+	var capture = fun () { return i * 10 + j; };
+	if (j == 0) first = capture; else second = capture;
+}
+var a = first();
+var b = second();
+`
+	whileCode := `
+var first;
+var second;
+{
+	var i = 0;
+	while (i < 2) {
+		{
+			var j = i;
+			var capture = fun () { return i * 10 + j; };
+			if (j == 0) first = capture; else second = capture;
+		}
+		i = i + 1;
+	}
+}
+var a = first();
+var b = second();
+`
+
+	results := make(map[string][]any)
+	for name, code := range map[string]string{"for": forCode, "while": whileCode} {
+		i, err := interpretTestCode(code)
+		if err != nil {
+			t.Fatalf("Expected no error in %s loop, got %v", name, err)
+		}
+		results[name] = []any{getGlobal(t, i, "a"), getGlobal(t, i, "b")}
+	}
+
+	// body variables are fresh per iteration in both loops, a `for` loop variable is too,
+	// while the `while` loop variable is declared once outside the loop
+	expected := map[string][]any{
+		"for":   {float64(0), float64(11)},
+		"while": {float64(20), float64(21)},
+	}
+	for name, result := range results {
+		if !slices.Equal(result, expected[name]) {
+			t.Errorf("Expected %s loop closures to return %v, got %v", name, expected[name], result)
+		}
+	}
+}
+
+func TestInterpreter_ForStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected any
+	}{
+		{"initializer is scoped to the loop", `
+var i = "outer";
+for (var i = 0; i < 3; i = i + 1) {}
+var result = i;
+`, "outer"},
+		{"continue still runs the increment", `
+var result = 0;
+for (var i = 0; i < 6; i = i + 1) {
+	if (i / 2 == 1 or i == 0 or i == 4) continue;
+	result = result + i;
+}
+`, float64(1 + 3 + 5)},
+		{"break leaves the loop", `
+var result = 0;
+for (;;) {
+	result = result + 1;
+	if (result == 3) break;
+}
+`, float64(3)},
+		{"break only leaves the innermost loop", `
+var result = 0;
+for (var i = 0; i < 3; i = i + 1) {
+	for (var j = 0; j < 3; j = j + 1) {
+		if (j == 1) break;
+		result = result + 1;
+	}
+}
+`, float64(3)},
+		{"break and continue in while", `
+var result = 0;
+var i = 0;
+while (true) {
+	i = i + 1;
+	if (i > 5) break;
+	if (i == 2) continue;
+	result = result + i;
+}
+`, float64(1 + 3 + 4 + 5)},
+		{"each iteration captures its own loop variable", `
+var first;
+for (var i = 0; i < 3; i = i + 1) {
+	if (i == 0) first = fun () { return i; };
+	if (i == 1) continue;
+}
+var result = first();
+`, float64(0)},
+		{"a closure assigning the loop variable updates the current iteration", `
+var result = 0;
+for (var i = 0; i < 10; i = i + 1) {
+	var skip = fun () { i = i + 1; };
+	skip();
+	result = result + 1;
+}
+`, float64(5)},
+		{"return from a loop", `
+fun find() {
+	for (var i = 0; ; i = i + 1) {
+		if (i == 4) return i;
+	}
+}
+var result = find();
+`, float64(4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := interpretTestCode(tt.code)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if result := getGlobal(t, i, "result"); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestInterpreter_EqualityEpsilon(t *testing.T) {
+	code := `
+var equal = 0.1 + 0.2 == 0.3;
+var notEqual = 0.1 + 0.2 != 0.3;
+var far = 1 == 1.5;
+var infinite = inf == inf;
+`
+	tests := []struct {
+		name     string
+		epsilon  float64
+		expected map[string]bool
+	}{
+		{"exact by default", 0, map[string]bool{"equal": false, "notEqual": true, "far": false, "infinite": true}},
+		{"with a tolerance", 1e-9, map[string]bool{"equal": true, "notEqual": false, "far": false, "infinite": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New()
+			i.SetEqualityEpsilon(tt.epsilon)
+			i.DefineGlobal("inf", math.Inf(1))
+			statements := parseCode(code)
+			err := NewResolver(i).ResolveStatements(statements)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			err = i.Interpret(statements)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				if value := getGlobal(t, i, name); value != expected {
+					t.Errorf("Expected %s to be %v, got %v", name, expected, value)
+				}
+			}
+		})
+	}
+}
+
+func TestInterpreter_PrintSelfReferentialValues(t *testing.T) {
+	list := NewList([]any{float64(1)})
+	list.Append(list)
+	m := NewMap()
+	m.Set("self", m)
+	m.Set("list", list)
+	shared := NewList([]any{float64(1)})
+
+	code := `
+class Node {}
+var node = Node();
+node.next = node;
+print list;
+print map;
+print twice;
+print node;
+`
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("list", list)
+	i.DefineGlobal("map", m)
+	i.DefineGlobal("twice", NewList([]any{shared, shared}))
+
+	err := i.Interpret(parseCode(code))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// a value seen twice without a cycle is printed in full, instances print without their fields
+	expected := "[1, [...]]\n{self: {...}, list: [1, [...]]}\n[[1], [1]]\nNode instance\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestInterpreter_UndefinedPropertySuggestion(t *testing.T) {
+	setup := `
+class Shape {
+	describe() { return "shape"; }
+}
+class Square < Shape {
+	area() { return this.size * this.size; }
+}
+var square = Square();
+square.size = 2;
+`
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{"misspelled field", "square.szie;", "undefined property 'szie' in instance of class 'Square'; did you mean 'size'?"},
+		{"misspelled method", "square.aera();", "undefined property 'aera' in instance of class 'Square'; did you mean 'area'?"},
+		{"misspelled inherited method", "square.describes();", "undefined property 'describes' in instance of class 'Square'; did you mean 'describe'?"},
+		{"nothing close", "square.perimeter;", "undefined property 'perimeter' in instance of class 'Square'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interpretTestCode(setup + tt.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+			if runtimeError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, runtimeError.Message)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ConditionExpression(t *testing.T) {
+	code := `
+var taken = true ? 1 : 2;
+var notTaken = nil ? 1 : 2;
+var calls = 0;
+fun count() { calls = calls + 1; return calls; }
+var shortCircuit = false ? count() : "skipped";
+fun pick(flag) {
+	var yes = "yes";
+	return flag ? yes : "no";
+}
+var local = pick(1);
+`
+	i, err := interpretTestCode(code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]any{
+		"taken":        float64(1),
+		"notTaken":     float64(2),
+		"shortCircuit": "skipped",
+		"calls":        float64(0),
+		"local":        "yes",
+	}
+	for name, value := range expected {
+		if actual := getGlobal(t, i, name); actual != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, actual)
+		}
+	}
+}
+
+func TestInterpreter_ConditionExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"error in the predicate", `var result = -"a" ? 1 : 2;`},
+		{"error in the taken branch", `var result = true ? -"a" : 2;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interpretTestCode(tt.code)
+
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("Expected RuntimeError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ReportErrorWritesToErrorOutput(t *testing.T) {
+	i := New()
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	i.SetOutput(&out)
+	i.SetErrorOutput(&errOut)
+
+	err := i.Interpret(parseCode("print \"before\";\nnil + 1;"))
+	if err == nil {
+		t.Fatalf("Expected a runtime error")
+	}
+	i.ReportError(err)
+	i.ReportError(errors.New("parse error: oops"))
+
+	if out.String() != "before\n" {
+		t.Errorf("Expected only the program output, got %q", out.String())
+	}
+	expected := "expected numbers/strings for addition, got <nil> and float64\n[line 2]\nparse error: oops\n"
+	if errOut.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, errOut.String())
+	}
+}
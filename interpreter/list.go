@@ -0,0 +1,53 @@
+package interpreter
+
+import "strings"
+
+// List is the runtime representation of a Lox list
+type List struct {
+	elements []any
+}
+
+func NewList(elements []any) *List {
+	return &List{
+		elements: elements,
+	}
+}
+
+func (l *List) Len() int {
+	return len(l.elements)
+}
+
+func (l *List) Get(index int) any {
+	return l.elements[index]
+}
+
+func (l *List) Append(value any) {
+	l.elements = append(l.elements, value)
+}
+
+func (l *List) String() string {
+	return l.format(nil)
+}
+
+// format prints the list, visiting holds the lists and maps being printed, a list containing itself prints as [...]
+func (l *List) format(visiting map[any]bool) string {
+	if visiting[l] {
+		return "[...]"
+	}
+	if visiting == nil {
+		visiting = make(map[any]bool)
+	}
+	visiting[l] = true
+	defer delete(visiting, l)
+
+	var b strings.Builder
+	b.WriteString("[")
+	for i, element := range l.elements {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(stringifyVisiting(element, visiting))
+	}
+	b.WriteString("]")
+	return b.String()
+}
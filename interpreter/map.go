@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"slices"
+	"strings"
+)
+
+// Map is the runtime representation of a Lox map.
+// Any value can be a key, keys are compared by their hash, see hashValue.
+// Keys are enumerated in insertion order.
+type Map struct {
+	entries map[string]mapEntry
+	// hashes of the keys in insertion order
+	order []string
+}
+
+type mapEntry struct {
+	key   any
+	value any
+}
+
+func NewMap() *Map {
+	return &Map{
+		entries: make(map[string]mapEntry),
+	}
+}
+
+func (m *Map) Len() int {
+	return len(m.entries)
+}
+
+func (m *Map) Get(key any) (any, bool) {
+	entry, ok := m.entries[hashValue(key)]
+	return entry.value, ok
+}
+
+// Set updates the value of an existing key in place, a new key goes after the others
+func (m *Map) Set(key any, value any) {
+	hash := hashValue(key)
+	if _, exists := m.entries[hash]; !exists {
+		m.order = append(m.order, hash)
+	}
+	m.entries[hash] = mapEntry{key: key, value: value}
+}
+
+// Delete removes key from the map, deleting a missing key does nothing
+func (m *Map) Delete(key any) {
+	hash := hashValue(key)
+	if _, exists := m.entries[hash]; !exists {
+		return
+	}
+	delete(m.entries, hash)
+	index := slices.Index(m.order, hash)
+	m.order = slices.Delete(m.order, index, index+1)
+}
+
+// Keys returns the keys of the map in insertion order
+func (m *Map) Keys() []any {
+	keys := make([]any, 0, len(m.order))
+	for _, hash := range m.order {
+		keys = append(keys, m.entries[hash].key)
+	}
+	return keys
+}
+
+func (m *Map) String() string {
+	return m.format(nil)
+}
+
+// format prints the map like List.format, a map containing itself prints as {...}
+func (m *Map) format(visiting map[any]bool) string {
+	if visiting[m] {
+		return "{...}"
+	}
+	if visiting == nil {
+		visiting = make(map[any]bool)
+	}
+	visiting[m] = true
+	defer delete(visiting, m)
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, key := range m.Keys() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		value, _ := m.Get(key)
+		b.WriteString(stringifyVisiting(key, visiting))
+		b.WriteString(": ")
+		b.WriteString(stringifyVisiting(value, visiting))
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package interpreter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMap_NumberAndStringKeys(t *testing.T) {
+	m := NewMap()
+	m.Set(float64(1), "number one")
+	m.Set("1", "string one")
+	m.Set(int64(2), "integer two")
+
+	testCases := []struct {
+		name     string
+		key      any
+		expected any
+	}{
+		{"number key", float64(1), "number one"},
+		{"string key", "1", "string one"},
+		{"equal numbers collide", float64(2), "integer two"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			value, ok := m.Get(testCase.key)
+			if !ok {
+				t.Fatalf("Expected key %v to exist", testCase.key)
+			}
+			if value != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, value)
+			}
+		})
+	}
+
+	if _, ok := m.Get(float64(3)); ok {
+		t.Errorf("Expected key 3 not to exist")
+	}
+}
+
+func TestMap_InstanceKeysUseIdentity(t *testing.T) {
+	class := NewClass("Foo", nil, nil)
+	foo1 := NewInstance(class)
+	foo2 := NewInstance(class)
+	m := NewMap()
+	m.Set(foo1, "foo1")
+
+	if value, ok := m.Get(foo1); !ok || value != "foo1" {
+		t.Errorf("Expected foo1, got %v", value)
+	}
+	if _, ok := m.Get(foo2); ok {
+		t.Errorf("Expected a different instance not to be found")
+	}
+}
+
+func TestMap_InsertionOrder(t *testing.T) {
+	m := NewMap()
+	m.Set("zebra", float64(1))
+	m.Set(float64(2), "two")
+	m.Set("apple", float64(3))
+	m.Set("mango", float64(4))
+	m.Set("zebra", float64(5))
+	m.Delete("apple")
+	m.Set("apple", float64(6))
+
+	i := New()
+	var out bytes.Buffer
+	i.SetOutput(&out)
+	i.DefineGlobal("m", m)
+
+	err := i.Interpret(parseCode("print m; print keys(m);"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "{zebra: 5, 2: two, mango: 4, apple: 6}\n[zebra, 2, mango, apple]\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ToLox converts a Go value into a Lox runtime value.
+// Numbers become float64, except int64 which is kept for integer mode,
+// slices become *List and string keyed maps become *Map.
+func ToLox(goValue any) (any, error) {
+	switch value := goValue.(type) {
+	case nil, float64, int64, string, bool:
+		return value, nil
+	case int:
+		return float64(value), nil
+	case []any:
+		elements := make([]any, 0, len(value))
+		for _, element := range value {
+			loxElement, err := ToLox(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, loxElement)
+		}
+		return NewList(elements), nil
+	case map[string]any:
+		// Go maps have no order, insert the keys sorted so the Lox map is deterministic
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		m := NewMap()
+		for _, key := range keys {
+			loxElement, err := ToLox(value[key])
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, loxElement)
+		}
+		return m, nil
+	case Callable, *Instance, *List, *Map:
+		// already a Lox value
+		return value, nil
+	default:
+		return nil, fmt.Errorf("can't convert Go value of type %T to Lox", goValue)
+	}
+}
+
+// FromLox converts a Lox runtime value into a plain Go value, the reverse of ToLox.
+// Integer mode numbers stay int64.
+func FromLox(loxValue any) (any, error) {
+	switch value := loxValue.(type) {
+	case nil, float64, int64, string, bool:
+		return value, nil
+	case *List:
+		elements := make([]any, 0, value.Len())
+		for _, element := range value.elements {
+			goElement, err := FromLox(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, goElement)
+		}
+		return elements, nil
+	case *Map:
+		m := make(map[string]any, value.Len())
+		for _, entry := range value.entries {
+			key, ok := entry.key.(string)
+			if !ok {
+				return nil, fmt.Errorf("can't convert Lox map with key of type %T to Go", entry.key)
+			}
+
+			goElement, err := FromLox(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = goElement
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("can't convert Lox value of type %T to Go", loxValue)
+	}
+}
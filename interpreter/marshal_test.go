@@ -0,0 +1,88 @@
+package interpreter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	goValue := []any{
+		map[string]any{"name": "foo", "count": 1, "tags": []any{"a", "b"}},
+		map[string]any{"name": "bar", "count": 2.5, "enabled": true, "parent": nil},
+	}
+
+	loxValue, err := ToLox(goValue)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	list, ok := loxValue.(*List)
+	if !ok {
+		t.Fatalf("Expected *List, got %T", loxValue)
+	}
+	first, ok := list.Get(0).(*Map)
+	if !ok {
+		t.Fatalf("Expected *Map, got %T", list.Get(0))
+	}
+	if count, _ := first.Get("count"); count != float64(1) {
+		t.Errorf("Expected count to be converted to float64 1, got %v (%T)", count, count)
+	}
+
+	actual, err := FromLox(loxValue)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []any{
+		map[string]any{"name": "foo", "count": float64(1), "tags": []any{"a", "b"}},
+		map[string]any{"name": "bar", "count": 2.5, "enabled": true, "parent": nil},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestMarshal_RoundTripInIntegerMode(t *testing.T) {
+	i := New()
+	i.SetIntegerMode(true)
+
+	loxValue, err := ToLox([]any{int64(9007199254740993), 1.5})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	i.DefineGlobal("values", loxValue)
+
+	err = i.Interpret(parseCode("var next = 9007199254740993 + 1;"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	next, err := FromLox(getGlobal(t, i, "next"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if next != int64(9007199254740994) {
+		t.Errorf("Expected exact int64 9007199254740994, got %v (%T)", next, next)
+	}
+
+	values, err := FromLox(getGlobal(t, i, "values"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []any{int64(9007199254740993), 1.5}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+}
+
+func TestMarshal_UnsupportedValues(t *testing.T) {
+	_, err := ToLox(struct{}{})
+	if err == nil {
+		t.Errorf("Expected error converting a struct to Lox")
+	}
+
+	_, err = FromLox(NewInstance(NewClass("Foo", nil, nil)))
+	if err == nil {
+		t.Errorf("Expected error converting an instance to Go")
+	}
+}
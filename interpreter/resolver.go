@@ -2,6 +2,8 @@ package interpreter
 
 import (
 	"fmt"
+	"slices"
+
 	"github.com/ocowchun/go-lox/ast"
 	"github.com/ocowchun/go-lox/token"
 )
@@ -13,6 +15,7 @@ const (
 	FunctionTypeFunction
 	FunctionTypeMethod
 	FunctionTypeInitializer
+	FunctionTypeStaticMethod
 )
 
 type ClassType uint8
@@ -28,13 +31,43 @@ type NameMetadata struct {
 
 	// Whether the name is used in the current/inner scope
 	used bool
+
+	// the name token inside the declaring node, nil for `this` and `super`
+	declaration *token.Token
+	// how many functions enclose the declaration, a use from a deeper function captures the name
+	functionDepth int
+	// how many names the scope declared before this one
+	order int
 }
 
 type Resolver struct {
-	interpreter         *Interpreter
-	scopes              []map[string]*NameMetadata
+	interpreter *Interpreter
+	scopes      []map[string]*NameMetadata
+	// names declared later in each scope, aligned with scopes
+	pendingDeclarations []map[string]bool
 	currentFunctionType FunctionType
 	currentClassType    ClassType
+
+	// when enabled, calls to top-level functions are checked against their declared arity
+	strictArity bool
+	// arity of the top-level functions, keyed by function name
+	functionArities map[string]int
+	// globals whose initializer is being resolved, top-level code has no scope to track them in
+	initializingGlobals map[string]bool
+	// whether the function being resolved has a `return value;`
+	returnsValue bool
+	// the body of the function being resolved, its locals must not conflict with the parameters
+	functionBody *ast.BlockStatement
+	// diagnostics that don't stop the program from running
+	warnings []*ResolveError
+	// errors of the last ResolveStatements, one per top-level statement that failed
+	errors []error
+	// when enabled, ResolveStatements fails with the first warning
+	warningsAsErrors bool
+	// how many functions enclose the code being resolved
+	functionDepth int
+	// how many loops of the current function enclose the code being resolved, `break` and `continue` need one
+	loopDepth int
 }
 
 func NewResolver(interpreter *Interpreter) *Resolver {
@@ -43,16 +76,59 @@ func NewResolver(interpreter *Interpreter) *Resolver {
 		scopes:              []map[string]*NameMetadata{},
 		currentFunctionType: FunctionTypeNone,
 		currentClassType:    ClassTypeNone,
+		functionArities:     make(map[string]int),
+		initializingGlobals: make(map[string]bool),
 	}
 }
 
+// SetStrictArity enables reporting arity mismatches of calls to top-level functions at resolve time
+func (r *Resolver) SetStrictArity(enabled bool) {
+	r.strictArity = enabled
+}
+
+// SetWarningsAsErrors makes ResolveStatements report the first warning as an error
+func (r *Resolver) SetWarningsAsErrors(enabled bool) {
+	r.warningsAsErrors = enabled
+}
+
+// Errors returns every error found by the last ResolveStatements, which only returns the first one
+func (r *Resolver) Errors() []error {
+	return r.errors
+}
+
+// Warnings returns the non-fatal diagnostics found so far
+func (r *Resolver) Warnings() []*ResolveError {
+	return r.warnings
+}
+
+func (r *Resolver) warn(token token.Token, message string) {
+	r.warnings = append(r.warnings, NewResolveError(token, message))
+}
+
+// ResolveStatements resolves a whole program and returns its first error.
+// A failing statement doesn't stop the resolution of the next ones, so Errors can report all of them.
 func (r *Resolver) ResolveStatements(statements []ast.Stmt) error {
+	// functions can be called before they are declared, so collect their arities first
+	for _, stmt := range statements {
+		if function, ok := stmt.(*ast.FunctionStatement); ok {
+			r.functionArities[function.Name.Lexeme] = len(function.Parameters)
+		}
+	}
+
+	r.errors = nil
 	for _, stmt := range statements {
 		err := r.ResolveStatement(stmt)
 		if err != nil {
-			return err
+			r.errors = append(r.errors, err)
 		}
 	}
+	if len(r.errors) > 0 {
+		return r.errors[0]
+	}
+
+	if r.warningsAsErrors && len(r.warnings) > 0 {
+		return r.warnings[0]
+	}
 	return nil
 }
 
@@ -75,6 +151,7 @@ func (r *Resolver) ResolveExpression(expr ast.Expr) error {
 func (r *Resolver) beginScope() {
 	scope := make(map[string]*NameMetadata)
 	r.scopes = append(r.scopes, scope)
+	r.pendingDeclarations = append(r.pendingDeclarations, make(map[string]bool))
 }
 
 func (r *Resolver) endScope() {
@@ -82,20 +159,28 @@ func (r *Resolver) endScope() {
 		panic("No scope to end")
 	}
 	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.pendingDeclarations = r.pendingDeclarations[:len(r.pendingDeclarations)-1]
 }
 
-func (r *Resolver) declare(name token.Token) error {
+// declare adds the name to the innermost scope, name points into the declaring node
+// so the declaration can be told apart from others with the same name
+func (r *Resolver) declare(name *token.Token) error {
 	if len(r.scopes) == 0 {
+		// globals can be redefined, so a REPL session can replace earlier definitions
 		return nil
 	}
 
 	scope := r.scopes[len(r.scopes)-1]
 	if _, exists := scope[name.Lexeme]; exists {
-		return NewResolveError(name, fmt.Sprintf("Already a variable with this name `%s` in this scope.", name.Lexeme))
+		return NewResolveError(*name, fmt.Sprintf("Already a variable with this name `%s` in this scope.", name.Lexeme))
 	}
+	delete(r.pendingDeclarations[len(r.pendingDeclarations)-1], name.Lexeme)
 	scope[name.Lexeme] = &NameMetadata{
-		initialized: false, // Mark as declared but not initialized
-		used:        false, // Not used yet
+		initialized:   false, // Mark as declared but not initialized
+		used:          false, // Not used yet
+		declaration:   name,
+		functionDepth: r.functionDepth,
+		order:         len(scope),
 	}
 
 	return nil
@@ -121,13 +206,22 @@ func (r *Resolver) VisitPrintStatement(stmt *ast.PrintStatement) any {
 }
 
 func (r *Resolver) VisitVarStatement(stmt *ast.VarStatement) any {
-	err := r.declare(stmt.Name)
+	if len(r.scopes) == 0 {
+		// the global is not a function anymore
+		delete(r.functionArities, stmt.Name.Lexeme)
+	}
+
+	err := r.declare(&stmt.Name)
 	if err != nil {
 		return err
 	}
 
 	if stmt.Initializer != nil {
+		if len(r.scopes) == 0 {
+			r.initializingGlobals[stmt.Name.Lexeme] = true
+		}
 		err = r.ResolveExpression(stmt.Initializer)
+		delete(r.initializingGlobals, stmt.Name.Lexeme)
 		if err != nil {
 			return err
 		}
@@ -144,6 +238,20 @@ func (r *Resolver) VisitVarStatement(stmt *ast.VarStatement) any {
 func (r *Resolver) VisitBlockStatement(stmt *ast.BlockStatement) any {
 	r.beginScope()
 	defer r.endScope()
+
+	// remember what the block declares, so using a name before its declaration isn't mistaken for a global
+	pending := r.pendingDeclarations[len(r.pendingDeclarations)-1]
+	for _, s := range stmt.Statements {
+		switch declaration := s.(type) {
+		case *ast.VarStatement:
+			pending[declaration.Name.Lexeme] = true
+		case *ast.FunctionStatement:
+			pending[declaration.Name.Lexeme] = true
+		case *ast.ClassStatement:
+			pending[declaration.Name.Lexeme] = true
+		}
+	}
+
 	for _, s := range stmt.Statements {
 		err := r.ResolveStatement(s)
 		if err != nil {
@@ -151,21 +259,33 @@ func (r *Resolver) VisitBlockStatement(stmt *ast.BlockStatement) any {
 		}
 	}
 
-	if r.currentFunctionType == FunctionTypeFunction {
+	blockScope := r.scopes[len(r.scopes)-1]
+	if stmt == r.functionBody {
 		parametersScope := r.scopes[len(r.scopes)-2]
-		blockScope := r.scopes[len(r.scopes)-1]
-		for name, metadata := range blockScope {
+		for name := range blockScope {
 			if _, ok := parametersScope[name]; ok {
 				return NewResolveError(token.Token{Lexeme: name}, fmt.Sprintf("Local variable `%s` conflicts with parameter.", name))
 			}
+		}
+	}
 
-			if !metadata.used {
-				return NewResolveError(token.Token{Lexeme: name}, fmt.Sprintf("Local variable `%s` is declared but never used.", name))
-			}
+	return unusedLocal(blockScope)
+}
+
+// unusedLocal reports the first declared name of scope that is never used
+func unusedLocal(scope map[string]*NameMetadata) error {
+	var unused *NameMetadata
+	for _, metadata := range scope {
+		if !metadata.used && metadata.declaration != nil && (unused == nil || metadata.order < unused.order) {
+			unused = metadata
 		}
 	}
+	if unused == nil {
+		return nil
+	}
 
-	return nil
+	name := *unused.declaration
+	return NewResolveError(name, fmt.Sprintf("Local variable `%s` is declared but never used.", name.Lexeme))
 }
 
 func (r *Resolver) VisitIfStatement(stmt *ast.IfStatement) any {
@@ -194,11 +314,163 @@ func (r *Resolver) VisitWhileStatement(stmt *ast.WhileStatement) any {
 		return err
 	}
 
-	return r.ResolveStatement(stmt.Body)
+	if condition, ok := constantValue(stmt.Condition); ok {
+		r.checkLoopCondition(stmt.Keyword, condition, stmt.Body)
+	}
+
+	return r.resolveLoopBody(stmt.Body)
+}
+
+func (r *Resolver) VisitForStatement(stmt *ast.ForStatement) any {
+	r.beginScope()
+	defer r.endScope()
+
+	if stmt.Initializer != nil {
+		err := r.ResolveStatement(stmt.Initializer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stmt.Condition == nil {
+		r.checkLoopCondition(stmt.Keyword, true, stmt.Body)
+	} else {
+		err := r.ResolveExpression(stmt.Condition)
+		if err != nil {
+			return err
+		}
+		if condition, ok := constantValue(stmt.Condition); ok {
+			r.checkLoopCondition(stmt.Keyword, condition, stmt.Body)
+		}
+	}
+
+	if stmt.Increment != nil {
+		err := r.ResolveExpression(stmt.Increment)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.resolveLoopBody(stmt.Body)
+	if err != nil {
+		return err
+	}
+
+	return unusedLocal(r.scopes[len(r.scopes)-1])
+}
+
+// checkLoopCondition warns about a loop whose condition is constant, only a `break` or a return can leave it
+// when the condition is always true
+func (r *Resolver) checkLoopCondition(keyword token.Token, condition any, body ast.Stmt) {
+	if !isTruthy(condition) {
+		r.warn(keyword, "Loop condition is always false, the body never runs.")
+	} else if !containsReturn(body) && !containsBreak(body) {
+		r.warn(keyword, "Loop condition is always true and the body never returns, the loop never terminates.")
+	}
+}
+
+func (r *Resolver) resolveLoopBody(body ast.Stmt) error {
+	r.loopDepth++
+	defer func() {
+		r.loopDepth--
+	}()
+
+	return r.ResolveStatement(body)
+}
+
+func (r *Resolver) VisitBreakStatement(stmt *ast.BreakStatement) any {
+	if r.loopDepth == 0 {
+		return NewResolveError(stmt.Keyword, "Can't use 'break' outside of a loop.")
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitContinueStatement(stmt *ast.ContinueStatement) any {
+	if r.loopDepth == 0 {
+		return NewResolveError(stmt.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+
+	return nil
+}
+
+// constantValue folds expressions made of literals, it reports false when expr depends on runtime values
+func constantValue(expr ast.Expr) (any, bool) {
+	switch e := expr.(type) {
+	case *ast.LiteralExpression:
+		return e.Value, true
+	case *ast.GroupingExpression:
+		return constantValue(e.Expression)
+	case *ast.UnaryExpression:
+		value, ok := constantValue(e.Right)
+		if !ok || e.Operator.Type != token.TokenTypeBang {
+			return nil, false
+		}
+		return !isTruthy(value), true
+	case *ast.LogicalExpression:
+		left, ok := constantValue(e.Left)
+		if !ok {
+			return nil, false
+		}
+		if e.Operator.Type == token.TokenTypeOr && isTruthy(left) || e.Operator.Type == token.TokenTypeAnd && !isTruthy(left) {
+			return left, true
+		}
+		return constantValue(e.Right)
+	default:
+		return nil, false
+	}
+}
+
+// containsReturn reports whether a return statement of the enclosing function appears in stmt,
+// the bodies of nested functions and classes don't count
+func containsReturn(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.BlockStatement:
+		return slices.ContainsFunc(s.Statements, containsReturn)
+	case *ast.IfStatement:
+		return containsReturn(s.ThenBranch) || (s.ElseBranch != nil && containsReturn(s.ElseBranch))
+	case *ast.WhileStatement:
+		return containsReturn(s.Body)
+	case *ast.RepeatStatement:
+		return containsReturn(s.Body)
+	case *ast.ForStatement:
+		return containsReturn(s.Body)
+	default:
+		return false
+	}
+}
+
+// containsBreak reports whether a `break` of the enclosing loop appears in stmt, the ones of nested loops don't count
+func containsBreak(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.BreakStatement:
+		return true
+	case *ast.BlockStatement:
+		return slices.ContainsFunc(s.Statements, containsBreak)
+	case *ast.IfStatement:
+		return containsBreak(s.ThenBranch) || (s.ElseBranch != nil && containsBreak(s.ElseBranch))
+	default:
+		return false
+	}
+}
+
+func (r *Resolver) VisitRepeatStatement(stmt *ast.RepeatStatement) any {
+	err := r.ResolveExpression(stmt.Count)
+	if err != nil {
+		return err
+	}
+
+	return r.resolveLoopBody(stmt.Body)
 }
 
 func (r *Resolver) VisitFunctionStatement(stmt *ast.FunctionStatement) any {
-	err := r.declare(stmt.Name)
+	if len(r.scopes) == 0 {
+		r.functionArities[stmt.Name.Lexeme] = len(stmt.Parameters)
+	}
+
+	err := r.declare(&stmt.Name)
 	if err != nil {
 		return err
 	}
@@ -208,32 +480,95 @@ func (r *Resolver) VisitFunctionStatement(stmt *ast.FunctionStatement) any {
 		return err
 	}
 
-	return r.resolveFunction(stmt.Parameters, stmt.Body, FunctionTypeFunction)
+	return r.resolveFunction(stmt.Name, stmt.Parameters, stmt.Body, FunctionTypeFunction)
 }
 
-func (r *Resolver) resolveFunction(parameters []token.Token, body *ast.BlockStatement, functionType FunctionType) error {
+// resolveFunction resolves a function body, name is the name of the function or the `fun` keyword of an anonymous one
+func (r *Resolver) resolveFunction(name token.Token, parameters []token.Token, body *ast.BlockStatement, functionType FunctionType) error {
 	enclosingFunctionType := r.currentFunctionType
+	enclosingReturnsValue := r.returnsValue
+	enclosingFunctionBody := r.functionBody
+	enclosingLoopDepth := r.loopDepth
 	r.currentFunctionType = functionType
+	r.returnsValue = false
+	r.functionBody = body
+	r.functionDepth++
+	// a loop around the function doesn't let its body break out of it
+	r.loopDepth = 0
 
 	r.beginScope()
 	defer func() {
 		r.currentFunctionType = enclosingFunctionType
+		r.returnsValue = enclosingReturnsValue
+		r.functionBody = enclosingFunctionBody
+		r.functionDepth--
+		r.loopDepth = enclosingLoopDepth
 		r.endScope()
 	}()
 
 	//parameter
-	for _, param := range parameters {
-		err := r.declare(param)
+	for i := range parameters {
+		err := r.declare(&parameters[i])
 		if err != nil {
 			return err
 		}
-		err = r.define(param)
+		err = r.define(parameters[i])
 		if err != nil {
 			return err
 		}
 	}
 
-	return r.ResolveStatement(body)
+	err := r.ResolveStatement(body)
+	if err != nil {
+		return err
+	}
+
+	if r.returnsValue && !alwaysReturns(body) {
+		label := fmt.Sprintf("Function '%s'", name.Lexeme)
+		if name.Type == token.TokenTypeFun {
+			label = "Anonymous function"
+		}
+		r.warn(name, fmt.Sprintf("%s returns a value on some paths but falls off the end on others.", label))
+	}
+
+	return nil
+}
+
+// alwaysReturns reports whether every path through stmt ends in a return statement
+func alwaysReturns(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.BlockStatement:
+		for _, statement := range s.Statements {
+			if alwaysReturns(statement) {
+				return true
+			}
+		}
+		return false
+	case *ast.IfStatement:
+		return s.ElseBranch != nil && alwaysReturns(s.ThenBranch) && alwaysReturns(s.ElseBranch)
+	case *ast.WhileStatement:
+		return neverExits(s.Condition, s.Body)
+	case *ast.ForStatement:
+		return neverExits(s.Condition, s.Body)
+	default:
+		return false
+	}
+}
+
+// neverExits reports whether a loop with condition and body can only be left by returning,
+// a missing condition, like the one of `for (;;)`, is always true
+func neverExits(condition ast.Expr, body ast.Stmt) bool {
+	if containsBreak(body) {
+		return false
+	}
+	if condition == nil {
+		return true
+	}
+
+	value, ok := constantValue(condition)
+	return ok && isTruthy(value)
 }
 
 func (r *Resolver) VisitReturnStatement(stmt *ast.ReturnStatement) any {
@@ -245,6 +580,7 @@ func (r *Resolver) VisitReturnStatement(stmt *ast.ReturnStatement) any {
 		if r.currentFunctionType == FunctionTypeInitializer {
 			return NewResolveError(stmt.Keyword, "Can't return a value from an initializer.")
 		}
+		r.returnsValue = true
 
 		return r.ResolveExpression(stmt.Value)
 	}
@@ -252,6 +588,14 @@ func (r *Resolver) VisitReturnStatement(stmt *ast.ReturnStatement) any {
 	return nil
 }
 
+func (r *Resolver) VisitDeferStatement(stmt *ast.DeferStatement) any {
+	if r.currentFunctionType == FunctionTypeNone {
+		return NewResolveError(stmt.Keyword, "Can't defer from top-level code.")
+	}
+
+	return r.ResolveExpression(stmt.Expression)
+}
+
 func (r *Resolver) VisitClassStatement(stmt *ast.ClassStatement) any {
 	enclosingClassType := r.currentClassType
 	r.currentClassType = ClassTypeClass
@@ -259,7 +603,7 @@ func (r *Resolver) VisitClassStatement(stmt *ast.ClassStatement) any {
 		r.currentClassType = enclosingClassType
 	}()
 
-	err := r.declare(stmt.Name)
+	err := r.declare(&stmt.Name)
 	if err != nil {
 		return err
 	}
@@ -286,6 +630,14 @@ func (r *Resolver) VisitClassStatement(stmt *ast.ClassStatement) any {
 		}
 	}
 
+	// static methods aren't bound to an instance, so they are resolved outside of the scope of `this`
+	for _, method := range stmt.StaticMethods {
+		err = r.resolveFunction(method.Name, method.Parameters, method.Body, FunctionTypeStaticMethod)
+		if err != nil {
+			return err
+		}
+	}
+
 	r.beginScope()
 	defer r.endScope()
 	r.scopes[len(r.scopes)-1]["this"] = &NameMetadata{
@@ -299,7 +651,7 @@ func (r *Resolver) VisitClassStatement(stmt *ast.ClassStatement) any {
 			declaration = FunctionTypeInitializer
 		}
 
-		err = r.resolveFunction(method.Parameters, method.Body, declaration)
+		err = r.resolveFunction(method.Name, method.Parameters, method.Body, declaration)
 		if err != nil {
 			return err
 		}
@@ -337,11 +689,71 @@ func (r *Resolver) VisitUnaryExpression(expr *ast.UnaryExpression) any {
 }
 
 func (r *Resolver) VisitCommaExpression(expr *ast.CommaExpression) any {
-	panic("TODO")
+	for _, subExpr := range expr.Expressions {
+		err := r.ResolveExpression(subExpr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *Resolver) VisitConditionExpression(expr *ast.ConditionExpression) any {
-	panic("TODO")
+	for _, subExpr := range []ast.Expr{expr.Predicate, expr.Consequent, expr.Alternative} {
+		err := r.ResolveExpression(subExpr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitMatchExpression(expr *ast.MatchExpression) any {
+	err := r.ResolveExpression(expr.Subject)
+	if err != nil {
+		return err
+	}
+
+	for _, arm := range expr.Arms {
+		if arm.Pattern != nil {
+			err = r.ResolveExpression(arm.Pattern)
+			if err != nil {
+				return err
+			}
+		}
+		err = r.resolveMatchArm(arm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveMatchArm resolves the guard and the body of arm, in a scope of their own when the arm binds a name
+func (r *Resolver) resolveMatchArm(arm *ast.MatchArm) error {
+	if arm.Binding != nil {
+		r.beginScope()
+		defer r.endScope()
+		err := r.declare(&arm.Binding.Name)
+		if err != nil {
+			return err
+		}
+		err = r.define(arm.Binding.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if arm.Guard != nil {
+		err := r.ResolveExpression(arm.Guard)
+		if err != nil {
+			return err
+		}
+	}
+	return r.ResolveExpression(arm.Body)
 }
 
 type ResolveError struct {
@@ -365,8 +777,15 @@ func (r *Resolver) resolveLocal(expr ast.Expr, name token.Token) error {
 		if metadata, ok := r.scopes[i][name.Lexeme]; ok {
 			r.interpreter.resolve(expr, len(r.scopes)-1-i)
 			metadata.used = true // Mark as used
+			if metadata.functionDepth < r.functionDepth && metadata.declaration != nil {
+				r.interpreter.capture(metadata.declaration)
+			}
 			return nil
 		}
+
+		if r.pendingDeclarations[i][name.Lexeme] {
+			return NewResolveError(name, fmt.Sprintf("Cannot use variable '%s' before its declaration.", name.Lexeme))
+		}
 	}
 	return nil
 }
@@ -384,6 +803,8 @@ func (r *Resolver) VisitVariableExpression(expr *ast.VariableExpression) any {
 			return NewResolveError(expr.Name, "Can't read local variable in its own initializer.")
 		}
 		metadata.used = true
+	} else if r.initializingGlobals[expr.Name.Lexeme] {
+		return NewResolveError(expr.Name, fmt.Sprintf("Can't read variable '%s' in its own initializer.", expr.Name.Lexeme))
 	}
 
 	return r.resolveLocal(expr, expr.Name)
@@ -396,9 +817,28 @@ func (r *Resolver) VisitAssignExpression(expr *ast.AssignExpression) any {
 		return err
 	}
 
+	if !r.isLocal(expr.Name) {
+		// the global might not be a function anymore
+		delete(r.functionArities, expr.Name.Lexeme)
+	}
+
 	return r.resolveLocal(expr, expr.Name)
 }
 
+func (r *Resolver) VisitPostfixExpression(expr *ast.PostfixExpression) any {
+	err := r.ResolveExpression(expr.Target)
+	if err != nil {
+		return err
+	}
+
+	if variable, ok := expr.Target.(*ast.VariableExpression); ok && !r.isLocal(variable.Name) {
+		// the global might not be a function anymore
+		delete(r.functionArities, variable.Name.Lexeme)
+	}
+
+	return nil
+}
+
 func (r *Resolver) VisitLogicalExpression(expr *ast.LogicalExpression) any {
 	err := r.ResolveExpression(expr.Left)
 	if err != nil {
@@ -421,11 +861,45 @@ func (r *Resolver) VisitCallExpression(expr *ast.CallExpression) any {
 		}
 	}
 
+	if r.strictArity {
+		return r.checkArity(expr)
+	}
+
 	return nil
 }
 
+// checkArity reports a mismatch between the arguments of a call and the parameters of the top-level function it calls.
+// Callees that can't be resolved statically are skipped, they are still checked at runtime.
+func (r *Resolver) checkArity(expr *ast.CallExpression) error {
+	callee, ok := expr.Callee.(*ast.VariableExpression)
+	if !ok || r.isLocal(callee.Name) {
+		return nil
+	}
+
+	arity, ok := r.functionArities[callee.Name.Lexeme]
+	if !ok || arity == len(expr.Arguments) {
+		return nil
+	}
+
+	return NewResolveError(
+		expr.Paren,
+		fmt.Sprintf("function '%s' expects %d arguments but got %d", callee.Name.Lexeme, arity, len(expr.Arguments)),
+	)
+}
+
+// isLocal reports whether name refers to a variable declared in one of the enclosing local scopes
+func (r *Resolver) isLocal(name token.Token) bool {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.Lexeme]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *Resolver) VisitFunctionExpression(expr *ast.FunctionExpression) any {
-	return r.resolveFunction(expr.Parameters, expr.Body, FunctionTypeFunction)
+	return r.resolveFunction(expr.Fun, expr.Parameters, expr.Body, FunctionTypeFunction)
 }
 
 func (r *Resolver) VisitGetExpression(expr *ast.GetExpression) any {
@@ -445,10 +919,17 @@ func (r *Resolver) VisitThisExpression(expr *ast.ThisExpression) any {
 	if r.currentClassType == ClassTypeNone {
 		return NewResolveError(expr.Keyword, "Can't use 'this' outside of a class.")
 	}
+	if r.currentFunctionType == FunctionTypeStaticMethod {
+		return NewResolveError(expr.Keyword, "Can't use 'this' in a static method.")
+	}
 
 	return r.resolveLocal(expr, expr.Keyword)
 }
 
 func (r *Resolver) VisitSuperExpression(expr *ast.SuperExpression) any {
+	if r.currentFunctionType == FunctionTypeStaticMethod {
+		return NewResolveError(expr.Keyword, "Can't use 'super' in a static method.")
+	}
+
 	return r.resolveLocal(expr, expr.Keyword)
 }
@@ -27,6 +27,39 @@ func TestResolver_LocalVariableCannotBeDeclaredTwice(t *testing.T) {
 	}
 }
 
+func TestResolver_GlobalFunctionCanBeRedefined(t *testing.T) {
+	code := `
+fun f() { return 1; }
+fun f() { return 2; }
+var f = 3;
+`
+
+	err := resolveTestCode(code)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestResolver_LocalFunctionCannotBeRedefined(t *testing.T) {
+	code := `
+{
+	fun f() { return 1; }
+	fun f() { return 2; }
+	print f();
+}`
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	} else {
+		if resolveError.Message != "Already a variable with this name `f` in this scope." {
+			t.Errorf("Expected specific error message, got %v", err)
+		}
+	}
+}
+
 func TestResolver_LocalVariableCannotShadowFunctionParameter(t *testing.T) {
 	code := `
 fun foo(x) {
@@ -66,6 +99,81 @@ fun foo() {
 	}
 }
 
+func TestResolver_LocalVariableMustBeUsedInAnyBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		line int
+	}{
+		{"bare block", `
+{
+	var a = 123;
+}
+`, 3},
+		{"nested block", `
+fun foo() {
+	var b = 1;
+	{
+		var a = 123;
+	}
+	print b;
+}
+`, 5},
+		{"block in method", `
+class Foo {
+	bar() {
+		if (true) {
+			var a = 123;
+		}
+	}
+}
+`, 5},
+		{"first of several unused locals", `
+{
+	var a = 1;
+	var z = 2;
+	var m = 3;
+	var b = 4;
+}
+`, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := resolveTestCode(tt.code)
+
+			var resolveError *ResolveError
+			if !errors.As(err, &resolveError) {
+				t.Fatalf("Expected ResolveError, got %T", err)
+			}
+			if resolveError.Message != "Local variable `a` is declared but never used." {
+				t.Errorf("Expected specific error message, got %v", err)
+			}
+			if resolveError.Token.Line != tt.line {
+				t.Errorf("Expected the error on line %d, got %d", tt.line, resolveError.Token.Line)
+			}
+		})
+	}
+}
+
+func TestResolver_NestedBlockCanShadowOuterLocal(t *testing.T) {
+	code := `
+fun foo(x) {
+	var a = x;
+	{
+		var a = 2;
+		print a;
+	}
+	print a;
+}
+`
+
+	err := resolveTestCode(code)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
 func TestResolver_LocalVariableUsd(t *testing.T) {
 	code := `
 fun foo() {
@@ -103,7 +211,6 @@ fun foo() {
 }
 
 func TestResolver_CannotReadFromOwnInitializer(t *testing.T) {
-	// TODO: return error in top level code for var a = a;
 	code := "{var a = a;}"
 
 	err := resolveTestCode(code)
@@ -118,6 +225,38 @@ func TestResolver_CannotReadFromOwnInitializer(t *testing.T) {
 	}
 }
 
+func TestResolver_CannotReadGlobalFromOwnInitializer(t *testing.T) {
+	code := "var a = a;"
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	} else {
+		if resolveError.Message != "Can't read variable 'a' in its own initializer." {
+			t.Errorf("Expected specific error message, got %v", err)
+		}
+	}
+}
+
+func TestResolver_GlobalInitializerCanReadOtherGlobals(t *testing.T) {
+	tests := []string{
+		"var b = 1; var a = b;",
+		"var b = 1; var a = b; var c = a;",
+		"var a = fun() { return a; };",
+	}
+
+	for _, code := range tests {
+		t.Run(code, func(t *testing.T) {
+			err := resolveTestCode(code)
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 func TestResolver_CannotReturnFromTopLevel(t *testing.T) {
 	code := `return 9527;`
 
@@ -154,6 +293,39 @@ class Foo {
 	}
 }
 
+func TestResolver_ReturnInMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"bare return in initializer", `
+class Foo {
+	init(skip) {
+		if (skip) return;
+		this.ready = true;
+	}
+}
+`},
+		{"bare and value returns in method", `
+class Foo {
+	bar(flag) {
+		if (flag) return;
+		return 123;
+	}
+}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := resolveTestCode(tt.code)
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 func TestResolver_ClassCannotInheritFromItself(t *testing.T) {
 	code := "class Oops < Oops {}"
 
@@ -191,3 +363,462 @@ func parseCode(code string) []ast.Stmt {
 	}
 	return statements
 }
+
+func TestResolver_StrictArityMatchingCall(t *testing.T) {
+	code := `
+fun add(a, b) {
+	return a + b;
+}
+add(1, 2);
+`
+
+	err := resolveStrictArityTestCode(code)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestResolver_StrictArityMismatchingCall(t *testing.T) {
+	code := `
+add(1, 2, 3);
+fun add(a, b) {
+	return a + b;
+}
+`
+
+	err := resolveStrictArityTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	} else {
+		if resolveError.Message != "function 'add' expects 2 arguments but got 3" {
+			t.Errorf("Expected specific error message, got %v", err)
+		}
+	}
+}
+
+func TestResolver_StrictAritySkipsShadowedFunction(t *testing.T) {
+	code := `
+fun add(a, b) {
+	return a + b;
+}
+fun foo(add) {
+	add(1);
+}
+`
+
+	err := resolveStrictArityTestCode(code)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func resolveStrictArityTestCode(code string) error {
+	interpreter := New()
+	resolver := NewResolver(interpreter)
+	resolver.SetStrictArity(true)
+
+	statements := parseCode(code)
+	return resolver.ResolveStatements(statements)
+}
+
+func TestResolver_CannotDeferFromTopLevel(t *testing.T) {
+	code := `defer clock();`
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	} else {
+		if resolveError.Message != "Can't defer from top-level code." {
+			t.Errorf("Expected specific error message, got %v", err)
+		}
+	}
+}
+
+func TestResolver_CannotUseVariableBeforeDeclaration(t *testing.T) {
+	code := `
+{
+	print x;
+	var x = 1;
+}
+`
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	} else {
+		if resolveError.Message != "Cannot use variable 'x' before its declaration." {
+			t.Errorf("Expected specific error message, got %v", err)
+		}
+		if resolveError.Token.Line != 3 {
+			t.Errorf("Expected error at line 3, got %d", resolveError.Token.Line)
+		}
+	}
+}
+
+func TestResolver_GlobalVariableUsedInBlock(t *testing.T) {
+	code := `
+var x = 1;
+{
+	print x;
+	var y = 2;
+	print y;
+}
+`
+
+	err := resolveTestCode(code)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestResolver_WarnsWhenNotAllPathsReturnAValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected []string
+	}{
+		{"return in one branch", `
+fun sign(x) {
+	if (x > 0) {
+		return 1;
+	} else {
+		print x;
+	}
+}
+`, []string{"Function 'sign' returns a value on some paths but falls off the end on others."}},
+		{"return in a loop", `
+var f = fun (x) {
+	while (x > 0) {
+		return x;
+	}
+};
+`, []string{"Anonymous function returns a value on some paths but falls off the end on others."}},
+		{"return in an infinite loop", `
+fun first(x) {
+	while (true) {
+		if (x > 0) return x;
+		x = x + 1;
+	}
+}
+fun next(x) {
+	for (;;) {
+		return x + 1;
+	}
+}
+`, nil},
+		{"return in an infinite loop with a break", `
+fun find(x) {
+	while (true) {
+		if (x > 10) break;
+		if (x > 0) return x;
+		x = x + 1;
+	}
+}
+`, []string{"Function 'find' returns a value on some paths but falls off the end on others."}},
+		{"return in both branches", `
+fun sign(x) {
+	if (x > 0) return 1; else return -1;
+}
+`, nil},
+		{"return after if", `
+fun sign(x) {
+	if (x > 0) {
+		return 1;
+	}
+	return -1;
+}
+`, nil},
+		{"no return value", `
+fun log(x) {
+	if (x) return;
+	print x;
+}
+`, nil},
+		{"nested function", `
+fun outer() {
+	fun inner() {
+		return 1;
+	}
+	print inner;
+}
+`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(New())
+			err := resolver.ResolveStatements(parseCode(tt.code))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			warnings := resolver.Warnings()
+			if len(warnings) != len(tt.expected) {
+				t.Fatalf("Expected %d warnings, got %v", len(tt.expected), warnings)
+			}
+			for i, warning := range warnings {
+				if warning.Message != tt.expected[i] {
+					t.Errorf("Expected %q, got %q", tt.expected[i], warning.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_CapturedVariables(t *testing.T) {
+	code := `
+{
+	var captured = 1;
+	var local = 2;
+	print local;
+	fun get() {
+		var inner = captured;
+		return inner;
+	}
+	print get();
+}
+`
+	interpreter := New()
+	statements := parseCode(code)
+	err := NewResolver(interpreter).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	block := statements[0].(*ast.BlockStatement)
+	captured := &block.Statements[0].(*ast.VarStatement).Name
+	local := &block.Statements[1].(*ast.VarStatement).Name
+	inner := &block.Statements[3].(*ast.FunctionStatement).Body.Statements[0].(*ast.VarStatement).Name
+
+	if !interpreter.IsCaptured(captured) {
+		t.Errorf("Expected `captured` to be captured by the nested function")
+	}
+	if interpreter.IsCaptured(local) {
+		t.Errorf("Expected `local` not to be captured")
+	}
+	if interpreter.IsCaptured(inner) {
+		t.Errorf("Expected `inner` not to be captured, it's only used in its own function")
+	}
+}
+
+func TestResolver_CapturedVariablesWithTheSameNameOnOneLine(t *testing.T) {
+	code := `
+{ var x = 1; fun get() { return x; } print get(); } { var x = 2; print x; }
+`
+	interpreter := New()
+	statements := parseCode(code)
+	err := NewResolver(interpreter).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	captured := &statements[0].(*ast.BlockStatement).Statements[0].(*ast.VarStatement).Name
+	other := &statements[1].(*ast.BlockStatement).Statements[0].(*ast.VarStatement).Name
+
+	if !interpreter.IsCaptured(captured) {
+		t.Errorf("Expected the first `x` to be captured by the nested function")
+	}
+	if interpreter.IsCaptured(other) {
+		t.Errorf("Expected the second `x` not to be captured, it only shares its name and line with the first")
+	}
+}
+
+func TestResolver_WarnsAboutConstantLoopConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected []string
+	}{
+		{"dead loop", `
+while (false and x) {
+	print 1;
+}
+`, []string{"Loop condition is always false, the body never runs."}},
+		{"unbreakable infinite loop", `
+for (;;) {
+	print 1;
+}
+`, []string{"Loop condition is always true and the body never returns, the loop never terminates."}},
+		{"infinite loop left with a return", `
+fun first() {
+	while (!nil) {
+		if (ready()) return;
+	}
+}
+`, nil},
+		{"return of a nested function doesn't leave the loop", `
+while (true) {
+	var f = fun () { return 1; };
+	f();
+}
+`, []string{"Loop condition is always true and the body never returns, the loop never terminates."}},
+		{"runtime condition", `
+var i = 0;
+while (i < 3) i = i + 1;
+`, nil},
+		{"infinite loop left with a break", `
+for (;;) {
+	if (ready()) break;
+}
+`, nil},
+		{"break of a nested loop doesn't leave the loop", `
+while (true) {
+	while (ready()) break;
+}
+`, []string{"Loop condition is always true and the body never returns, the loop never terminates."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(New())
+			err := resolver.ResolveStatements(parseCode(tt.code))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			warnings := resolver.Warnings()
+			if len(warnings) != len(tt.expected) {
+				t.Fatalf("Expected %d warnings, got %v", len(tt.expected), warnings)
+			}
+			for i, warning := range warnings {
+				if warning.Message != tt.expected[i] {
+					t.Errorf("Expected %q, got %q", tt.expected[i], warning.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_ThisInStaticMethod(t *testing.T) {
+	code := `
+class Foo {
+	class create() {
+		return this;
+	}
+}
+`
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	}
+	if resolveError.Message != "Can't use 'this' in a static method." {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestResolver_ReportsEveryFailingStatement(t *testing.T) {
+	code := `
+return 1;
+var fine = 2;
+class Foo < Foo {}
+print fine;
+`
+	resolver := NewResolver(New())
+	err := resolver.ResolveStatements(parseCode(code))
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	}
+	if resolveError.Message != "Can't return from top-level code." {
+		t.Errorf("Expected the first error to be returned, got %v", err)
+	}
+
+	expected := []string{"Can't return from top-level code.", "A class can't inherit from itself."}
+	errs := resolver.Errors()
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestResolver_SuperInStaticMethod(t *testing.T) {
+	code := `
+class Base {
+	class create() {
+		return Base();
+	}
+}
+class Derived < Base {
+	class create() {
+		return super.create();
+	}
+}
+`
+
+	err := resolveTestCode(code)
+
+	var resolveError *ResolveError
+	if !errors.As(err, &resolveError) {
+		t.Fatalf("Expected ResolveError, got %T", err)
+	}
+	if resolveError.Message != "Can't use 'super' in a static method." {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestResolver_LoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{"break at top level", "break;", "Can't use 'break' outside of a loop."},
+		{"continue at top level", "continue;", "Can't use 'continue' outside of a loop."},
+		{"break in a function inside a loop", "while (true) { fun f() { break; } f(); }", "Can't use 'break' outside of a loop."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := resolveTestCode(tt.code)
+
+			var resolveError *ResolveError
+			if !errors.As(err, &resolveError) {
+				t.Fatalf("Expected ResolveError, got %T", err)
+			}
+			if resolveError.Message != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, resolveError.Message)
+			}
+		})
+	}
+}
+
+func TestResolver_CommaExpression(t *testing.T) {
+	code := `
+fun f() {
+	var a = 1;
+	var b = 2;
+	a, b;
+}
+`
+	statements := parseCode(code)
+	err := NewResolver(New()).ResolveStatements(statements)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body := statements[0].(*ast.FunctionStatement).Body
+	comma := body.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.CommaExpression)
+	for _, expression := range comma.Expressions {
+		variable := expression.(*ast.VariableExpression)
+		if variable.Resolution != (ast.Resolution{Local: true, Depth: 0}) {
+			t.Errorf("Expected %s to resolve to the function body, got %+v", variable.Name.Lexeme, variable.Resolution)
+		}
+	}
+}
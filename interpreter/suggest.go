@@ -0,0 +1,52 @@
+package interpreter
+
+import "slices"
+
+// maxSuggestionDistance is how many edits a misspelled name can be from the name it suggests
+const maxSuggestionDistance = 2
+
+// closestName returns the candidate closest to name by edit distance, ties go to the alphabetically first one.
+// It reports false when no candidate is within maxSuggestionDistance, a candidate sharing nothing with name,
+// like `a` for `c`, is never suggested.
+func closestName(name string, candidates []string) (string, bool) {
+	candidates = slices.Clone(candidates)
+	slices.Sort(candidates)
+
+	closest := ""
+	closestDistance := maxSuggestionDistance + 1
+	for _, candidate := range candidates {
+		distance := levenshtein(name, candidate)
+		if distance >= max(len(name), len(candidate)) {
+			continue
+		}
+		if distance < closestDistance {
+			closest = candidate
+			closestDistance = distance
+		}
+	}
+
+	return closest, closestDistance <= maxSuggestionDistance
+}
+
+// levenshtein returns the number of single byte insertions, deletions and substitutions turning a into b
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			substitution := previous[j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/ocowchun/go-lox/token"
 )
@@ -13,6 +14,13 @@ type Lexer struct {
 	start   int
 	current int
 	line    int
+
+	// PreserveComments emits comments as LineComment and BlockComment tokens instead of skipping them,
+	// the parser doesn't expect them, so it's meant for tools like formatters
+	PreserveComments bool
+
+	// type of the last token scanned, comments aside, `++` and `--` are only lexed right after an operand
+	previous token.TokenType
 }
 
 func New(input string) *Lexer {
@@ -29,7 +37,7 @@ func (l *Lexer) Tokens() ([]token.Token, error) {
 
 	for !l.IsAtEnd() {
 
-		t, err := l.NextToken()
+		t, err := l.Next()
 		if err != nil {
 			return tokens, err
 		}
@@ -44,6 +52,12 @@ func (l *Lexer) Tokens() ([]token.Token, error) {
 	return tokens, nil
 }
 
+// Next scans and returns one token at a time, it keeps returning an EOF token once the source is exhausted.
+// Unlike Tokens, it lets a parser pull tokens as it needs them.
+func (l *Lexer) Next() (token.Token, error) {
+	return l.NextToken()
+}
+
 func (l *Lexer) IsAtEnd() bool {
 	return l.current >= len(l.source)
 }
@@ -87,35 +101,57 @@ func (l *Lexer) peekNext() byte {
 }
 
 func (l *Lexer) NextToken() (token.Token, error) {
+	t, err := l.scanToken()
+	if err == nil && !t.IsTokenType(token.TokenTypeLineComment) && !t.IsTokenType(token.TokenTypeBlockComment) {
+		l.previous = t.Type
+	}
+	return t, err
+}
+
+// afterOperand reports whether the last token can be the target of `++` or `--`,
+// elsewhere `1--1` keeps meaning `1 - -1`
+func (l *Lexer) afterOperand() bool {
+	return l.previous == token.TokenTypeIdentifier || l.previous == token.TokenTypeRightParen
+}
+
+func (l *Lexer) scanToken() (token.Token, error) {
 	for !l.IsAtEnd() {
 		l.start = l.current
 
 		c := l.Advance()
 		switch c {
 		case '(':
-			return token.Token{Type: token.TokenTypeLeftParen, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeLeftParen, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case ')':
-			return token.Token{Type: token.TokenTypeRightParen, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeRightParen, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '{':
-			return token.Token{Type: token.TokenTypeLeftBrace, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeLeftBrace, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '}':
-			return token.Token{Type: token.TokenTypeRightBrace, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeRightBrace, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case ',':
-			return token.Token{Type: token.TokenTypeComma, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeComma, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '.':
-			return token.Token{Type: token.TokenTypeDot, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeDot, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '-':
-			return token.Token{Type: token.TokenTypeMinus, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			if l.afterOperand() && l.match('-') {
+				return token.Token{Type: token.TokenTypeMinusMinus, Lexeme: "--", Literal: nil, Line: l.line}, nil
+			} else {
+				return token.Token{Type: token.TokenTypeMinus, Lexeme: "-", Literal: nil, Line: l.line}, nil
+			}
 		case '+':
-			return token.Token{Type: token.TokenTypePlus, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			if l.afterOperand() && l.match('+') {
+				return token.Token{Type: token.TokenTypePlusPlus, Lexeme: "++", Literal: nil, Line: l.line}, nil
+			} else {
+				return token.Token{Type: token.TokenTypePlus, Lexeme: "+", Literal: nil, Line: l.line}, nil
+			}
 		case '*':
-			return token.Token{Type: token.TokenTypeStar, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeStar, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case ';':
-			return token.Token{Type: token.TokenTypeSemicolon, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeSemicolon, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '?':
-			return token.Token{Type: token.TokenTypeQuestionMark, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeQuestionMark, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case ':':
-			return token.Token{Type: token.TokenTypeColon, Lexeme: string(c), Literal: nil, Line: l.line}, nil
+			return token.Token{Type: token.TokenTypeColon, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, nil
 		case '!':
 			if l.match('=') {
 				return token.Token{Type: token.TokenTypeBangEqual, Lexeme: "!=", Literal: nil, Line: l.line}, nil
@@ -125,6 +161,8 @@ func (l *Lexer) NextToken() (token.Token, error) {
 		case '=':
 			if l.match('=') {
 				return token.Token{Type: token.TokenTypeEqualEqual, Lexeme: "==", Literal: nil, Line: l.line}, nil
+			} else if l.match('>') {
+				return token.Token{Type: token.TokenTypeArrow, Lexeme: "=>", Literal: nil, Line: l.line}, nil
 			} else {
 				return token.Token{Type: token.TokenTypeEqual, Lexeme: "=", Literal: nil, Line: l.line}, nil
 			}
@@ -146,6 +184,21 @@ func (l *Lexer) NextToken() (token.Token, error) {
 					l.Advance()
 				}
 
+				if l.PreserveComments {
+					text := l.source[l.start:l.current]
+					return token.Token{Type: token.TokenTypeLineComment, Lexeme: text, Literal: text[2:], Line: l.line}, nil
+				}
+			} else if l.match('*') {
+				line := l.line
+				err := l.skipBlockComment()
+				if err != nil {
+					return token.Token{Type: token.TokenTypeEOF, Lexeme: "", Literal: nil, Line: line}, err
+				}
+
+				if l.PreserveComments {
+					text := l.source[l.start:l.current]
+					return token.Token{Type: token.TokenTypeBlockComment, Lexeme: text, Literal: text[2 : len(text)-2], Line: line}, nil
+				}
 			} else {
 				return token.Token{Type: token.TokenTypeSlash, Lexeme: "/", Literal: nil, Line: l.line}, nil
 			}
@@ -170,7 +223,7 @@ func (l *Lexer) NextToken() (token.Token, error) {
 			} else if isAlpha(c) {
 				return l.nextKeywordOrIdentifier()
 			}
-			return token.Token{Type: token.TokenTypeEOF, Lexeme: string(c), Literal: nil, Line: l.line}, fmt.Errorf("Unexpected character %x", c)
+			return token.Token{Type: token.TokenTypeEOF, Lexeme: singleCharLexeme(c), Literal: nil, Line: l.line}, l.unexpectedCharacterError(c)
 
 		}
 	}
@@ -178,6 +231,47 @@ func (l *Lexer) NextToken() (token.Token, error) {
 	return token.Token{Type: token.TokenTypeEOF, Lexeme: "", Literal: nil, Line: l.line}, nil
 }
 
+// skipBlockComment consumes a /* */ comment after its opening, block comments don't nest
+func (l *Lexer) skipBlockComment() error {
+	line := l.line
+	for !(l.peek() == '*' && l.peekNext() == '/') {
+		if l.IsAtEnd() {
+			return fmt.Errorf("[line %d] unterminated block comment", line)
+		}
+		if l.Advance() == '\n' {
+			l.line++
+		}
+	}
+
+	l.Advance()
+	l.Advance()
+	return nil
+}
+
+// singleCharLexemes holds the lexeme of every ASCII character, converting a byte with string(c) allocates
+var singleCharLexemes = func() (lexemes [utf8.RuneSelf]string) {
+	for c := range lexemes {
+		lexemes[c] = string(rune(c))
+	}
+	return lexemes
+}()
+
+func singleCharLexeme(c byte) string {
+	if c < utf8.RuneSelf {
+		return singleCharLexemes[c]
+	}
+	return string(c)
+}
+
+func (l *Lexer) unexpectedCharacterError(c byte) error {
+	if c >= 0x20 && c < 0x7f {
+		return fmt.Errorf("[line %d] unexpected character '%c'", l.line, c)
+	}
+
+	// non-printable characters would be invisible in the message
+	return fmt.Errorf("[line %d] unexpected character 0x%02x", l.line, c)
+}
+
 func isAlpha(c byte) bool {
 	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
 }
@@ -188,42 +282,19 @@ func (l *Lexer) nextKeywordOrIdentifier() (token.Token, error) {
 	}
 
 	str := l.source[l.start:l.current]
-	switch str {
-	case "and":
-		return token.Token{Type: token.TokenTypeAnd, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "class":
-		return token.Token{Type: token.TokenTypeClass, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "else":
-		return token.Token{Type: token.TokenTypeElse, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "false":
-		return token.Token{Type: token.TokenTypeFalse, Lexeme: str, Literal: false, Line: l.line}, nil
-	case "for":
-		return token.Token{Type: token.TokenTypeFor, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "fun":
-		return token.Token{Type: token.TokenTypeFun, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "if":
-		return token.Token{Type: token.TokenTypeIf, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "nil":
-		return token.Token{Type: token.TokenTypeNil, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "or":
-		return token.Token{Type: token.TokenTypeOr, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "print":
-		return token.Token{Type: token.TokenTypePrint, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "return":
-		return token.Token{Type: token.TokenTypeReturn, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "super":
-		return token.Token{Type: token.TokenTypeSuper, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "this":
-		return token.Token{Type: token.TokenTypeThis, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "true":
-		return token.Token{Type: token.TokenTypeTrue, Lexeme: str, Literal: true, Line: l.line}, nil
-	case "var":
-		return token.Token{Type: token.TokenTypeVar, Lexeme: str, Literal: nil, Line: l.line}, nil
-	case "while":
-		return token.Token{Type: token.TokenTypeWhile, Lexeme: str, Literal: nil, Line: l.line}, nil
-	default:
+	tokenType, ok := token.Keyword(str)
+	if !ok {
 		return token.Token{Type: token.TokenTypeIdentifier, Lexeme: str, Literal: nil, Line: l.line}, nil
 	}
+
+	var literal any
+	switch tokenType {
+	case token.TokenTypeTrue:
+		literal = true
+	case token.TokenTypeFalse:
+		literal = false
+	}
+	return token.Token{Type: tokenType, Lexeme: str, Literal: literal, Line: l.line}, nil
 }
 
 func isDigit(c byte) bool {
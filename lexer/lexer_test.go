@@ -1,14 +1,17 @@
 package lexer
 
 import (
+	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/ocowchun/go-lox/token"
 )
 
 func TestLexer(t *testing.T) {
-	input := "( ) { } , . - + * ; ! != = == < <= > >= / 123 \"hello lexer\" foo and class else false for fun if nil or print return super this true var while"
+	input := "( ) { } , . - + * ; ! != = == => < <= > >= / 123 \"hello lexer\" foo and class else false for fun if match nil or print return super this true var while"
 	l := New(input)
 
 	expectedTokens := []token.Token{
@@ -26,6 +29,7 @@ func TestLexer(t *testing.T) {
 		token.Token{Type: token.TokenTypeBangEqual},
 		token.Token{Type: token.TokenTypeEqual},
 		token.Token{Type: token.TokenTypeEqualEqual},
+		token.Token{Type: token.TokenTypeArrow},
 		token.Token{Type: token.TokenTypeLess},
 		token.Token{Type: token.TokenTypeLessEqual},
 		token.Token{Type: token.TokenTypeGreater},
@@ -41,6 +45,7 @@ func TestLexer(t *testing.T) {
 		token.Token{Type: token.TokenTypeFor},
 		token.Token{Type: token.TokenTypeFun},
 		token.Token{Type: token.TokenTypeIf},
+		token.Token{Type: token.TokenTypeMatch},
 		token.Token{Type: token.TokenTypeNil},
 		token.Token{Type: token.TokenTypeOr},
 		token.Token{Type: token.TokenTypePrint},
@@ -106,3 +111,139 @@ func assertToken(t *testing.T, actualToken token.Token, expectedToken token.Toke
 		}
 	}
 }
+
+func TestLexer_UnexpectedCharacter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"printable character", "var a = 1;\na @ 2;", "[line 2] unexpected character '@'"},
+		{"non-printable character", "\x01", "[line 1] unexpected character 0x01"},
+		{"unterminated block comment", "1;\n/* never\nclosed", "[line 2] unterminated block comment"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := New(testCase.input).Tokens()
+			if err == nil {
+				t.Fatalf("Expected error for input %q, but got none", testCase.input)
+			}
+			if err.Error() != testCase.expected {
+				t.Errorf("Expected %q, got %q", testCase.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestLexer_IncrementOnlyAfterAnOperand(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []token.TokenType
+	}{
+		{"1--1", []token.TokenType{token.TokenTypeNumber, token.TokenTypeMinus, token.TokenTypeMinus, token.TokenTypeNumber}},
+		{"1 - -1", []token.TokenType{token.TokenTypeNumber, token.TokenTypeMinus, token.TokenTypeMinus, token.TokenTypeNumber}},
+		{"1++1", []token.TokenType{token.TokenTypeNumber, token.TokenTypePlus, token.TokenTypePlus, token.TokenTypeNumber}},
+		{"x--", []token.TokenType{token.TokenTypeIdentifier, token.TokenTypeMinusMinus}},
+		{"x+++1", []token.TokenType{token.TokenTypeIdentifier, token.TokenTypePlusPlus, token.TokenTypePlus, token.TokenTypeNumber}},
+		{"f() ++", []token.TokenType{token.TokenTypeIdentifier, token.TokenTypeLeftParen, token.TokenTypeRightParen, token.TokenTypePlusPlus}},
+		{"x /* note */ --", []token.TokenType{token.TokenTypeIdentifier, token.TokenTypeMinusMinus}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			tokens, err := New(testCase.input).Tokens()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			types := make([]token.TokenType, 0, len(tokens))
+			for _, tok := range tokens {
+				types = append(types, tok.Type)
+			}
+			if !slices.Equal(types, testCase.expected) {
+				t.Errorf("Expected %v, got %v", testCase.expected, types)
+			}
+		})
+	}
+}
+
+func TestLexer_PreserveComments(t *testing.T) {
+	input := "// answer\nvar a = /* the\nanswer */ 42;"
+
+	t.Run("default", func(t *testing.T) {
+		tokens, err := New(input).Tokens()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(tokens) != 5 {
+			t.Fatalf("Expected comments to be skipped, got %d tokens", len(tokens))
+		}
+		if tokens[4].Line != 3 {
+			t.Errorf("Expected `;` on line 3, got %d", tokens[4].Line)
+		}
+	})
+
+	t.Run("preserved", func(t *testing.T) {
+		l := New(input)
+		l.PreserveComments = true
+		tokens, err := l.Tokens()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedTokens := []token.Token{
+			{Type: token.TokenTypeLineComment, Lexeme: "// answer", Literal: " answer", Line: 1},
+			{Type: token.TokenTypeVar, Lexeme: "var", Line: 2},
+			{Type: token.TokenTypeIdentifier, Lexeme: "a", Line: 2},
+			{Type: token.TokenTypeEqual, Lexeme: "=", Line: 2},
+			{Type: token.TokenTypeBlockComment, Lexeme: "/* the\nanswer */", Literal: " the\nanswer ", Line: 2},
+			{Type: token.TokenTypeNumber, Lexeme: "42", Literal: float64(42), Line: 3},
+			{Type: token.TokenTypeSemicolon, Lexeme: ";", Line: 3},
+		}
+		if len(tokens) != len(expectedTokens) {
+			t.Fatalf("Expected %d tokens, got %d", len(expectedTokens), len(tokens))
+		}
+		for i, expected := range expectedTokens {
+			if !expected.Equal(tokens[i]) {
+				t.Errorf("Expected %v, got %v", expected, tokens[i])
+			}
+		}
+	})
+}
+
+func TestLexer_SingleCharTokensDontAllocate(t *testing.T) {
+	source := "(){},.-+;*!=<>/"
+	allocs := testing.AllocsPerRun(100, func() {
+		l := New(source)
+		for !l.IsAtEnd() {
+			_, err := l.Next()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+	})
+
+	// one allocation for the lexer itself
+	if allocs > 1 {
+		t.Errorf("Expected single char tokens not to allocate, got %v allocations", allocs)
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	var source strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&source, "fun f%d(a, b) {\n  var c = a * %d + b; // comment\n  if (c >= 10) { print \"big\"; }\n  return c;\n}\n", i, i)
+	}
+	input := source.String()
+
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := New(input).Tokens()
+		if err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
@@ -6,35 +6,161 @@ import (
 	"slices"
 
 	"github.com/ocowchun/go-lox/ast"
+	"github.com/ocowchun/go-lox/lexer"
 	"github.com/ocowchun/go-lox/token"
 )
 
+// TokenSource produces tokens on demand, like *lexer.Lexer, it returns an EOF token once it runs out
+type TokenSource interface {
+	Next() (token.Token, error)
+}
+
 type Parser struct {
 	tokens  []token.Token
 	current int
+
+	// source fills tokens as the parser looks ahead, nil once exhausted or when parsing a prebuilt slice
+	source TokenSource
+	// sourceErr is the error that stopped source, it takes precedence over the parse error it causes
+	sourceErr error
+
+	// EnableCommaOperator makes `a, b` an expression, when disabled commas only separate arguments
+	EnableCommaOperator bool
 }
 
 func NewParser(tokens []token.Token) *Parser {
 	return &Parser{
-		tokens:  tokens,
-		current: 0,
+		tokens:              tokens,
+		current:             0,
+		EnableCommaOperator: true,
+	}
+}
+
+// NewParserFromSource lexes source and returns a parser over its tokens, or the lexer error
+func NewParserFromSource(source string) (*Parser, error) {
+	tokens, err := lexer.New(source).Tokens()
+	if err != nil {
+		return nil, err
 	}
+
+	return NewParser(tokens), nil
 }
 
+// NewStreamingParser returns a parser pulling tokens from source as it needs them, instead of a prebuilt slice
+func NewStreamingParser(source TokenSource) *Parser {
+	p := NewParser(make([]token.Token, 0))
+	p.source = source
+	return p
+}
+
+// ParseError is a syntax error, Token is the token the parser stopped at
+type ParseError struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+// Parse parses the whole source. After a syntax error it skips to the next statement and keeps going,
+// so it returns every *ParseError, joined by errors.Join when there are several.
 func (p *Parser) Parse() ([]ast.Stmt, error) {
 	statements := make([]ast.Stmt, 0)
-	for p.current != len(p.tokens) && !p.currentTokenIs(token.TokenTypeEOF) {
+	var errs []error
+	for !p.currentTokenIs(token.TokenTypeEOF) {
+		start := p.current
 		stmt, err := p.ParseDeclaration()
 		if err != nil {
-			return nil, err
+			if p.sourceErr != nil {
+				return nil, p.sourceErr
+			}
+			errs = append(errs, &ParseError{Token: p.currentToken(), Message: err.Error()})
+			p.synchronize(start)
+			continue
 		}
 		statements = append(statements, stmt)
 
 	}
+	if p.sourceErr != nil {
+		return nil, p.sourceErr
+	}
 
+	if len(errs) == 1 {
+		return nil, errs[0]
+	} else if len(errs) > 1 {
+		return nil, errors.Join(errs...)
+	}
 	return statements, nil
 }
 
+// synchronize discards tokens until the start of the next top-level statement, so parsing can resume after a syntax error.
+// start is the index of the first token of the failed statement, the braces and parentheses it opened are skipped until they are closed.
+func (p *Parser) synchronize(start int) {
+	depth := 0
+	for _, t := range p.tokens[start:p.current] {
+		depth += nesting(t)
+	}
+
+	for !p.currentTokenIs(token.TokenTypeEOF) {
+		t, err := p.advance()
+		if err != nil {
+			return
+		}
+		depth += nesting(t)
+		if depth > 0 {
+			continue
+		}
+
+		if t.Type == token.TokenTypeSemicolon || t.Type == token.TokenTypeRightBrace {
+			return
+		}
+		if p.currentTokenIs(
+			token.TokenTypeClass, token.TokenTypeFun, token.TokenTypeVar, token.TokenTypeFor,
+			token.TokenTypeIf, token.TokenTypeWhile, token.TokenTypePrint, token.TokenTypeReturn,
+		) {
+			return
+		}
+	}
+}
+
+// nesting is how much t changes the depth of braces and parentheses
+func nesting(t token.Token) int {
+	switch t.Type {
+	case token.TokenTypeLeftBrace, token.TokenTypeLeftParen:
+		return 1
+	case token.TokenTypeRightBrace, token.TokenTypeRightParen:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// ParseExpression parses the tokens as a single expression, all tokens must be consumed
+func (p *Parser) ParseExpression() (ast.Expr, error) {
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, p.wrapSourceError(err)
+	}
+
+	if !p.currentTokenIs(token.TokenTypeEOF) {
+		return nil, fmt.Errorf("unexpected token %s after expression", p.currentToken().Lexeme)
+	}
+	if p.sourceErr != nil {
+		return nil, p.sourceErr
+	}
+
+	return expr, nil
+}
+
+// wrapSourceError prefers the error of the token source, the parse error is usually a consequence of it
+func (p *Parser) wrapSourceError(err error) error {
+	if p.sourceErr != nil {
+		return p.sourceErr
+	}
+	return err
+}
+
 func (p *Parser) ParseDeclaration() (ast.Stmt, error) {
 	if p.currentTokenIs(token.TokenTypeVar) {
 		return p.parseVarDeclaration()
@@ -85,7 +211,21 @@ func (p *Parser) parseClassDeclaration() (*ast.ClassStatement, error) {
 
 	_, err = p.consume(token.TokenTypeLeftBrace, "expected `{` after class name")
 	methods := make([]*ast.FunctionStatement, 0)
+	staticMethods := make([]*ast.FunctionStatement, 0)
 	for !p.currentTokenIs(token.TokenTypeRightBrace) {
+		if p.currentTokenIs(token.TokenTypeClass) {
+			_, err := p.advance()
+			if err != nil {
+				return nil, err
+			}
+			method, err := p.parseFunctionStatement("static method")
+			if err != nil {
+				return nil, err
+			}
+			staticMethods = append(staticMethods, method)
+			continue
+		}
+
 		method, err := p.parseFunctionStatement("method")
 		if err != nil {
 			return nil, err
@@ -96,9 +236,10 @@ func (p *Parser) parseClassDeclaration() (*ast.ClassStatement, error) {
 	_, err = p.consume(token.TokenTypeRightBrace, "expected `}` after class body")
 
 	return &ast.ClassStatement{
-		Name:       name,
-		Superclass: superclass,
-		Methods:    methods,
+		Name:          name,
+		Superclass:    superclass,
+		Methods:       methods,
+		StaticMethods: staticMethods,
 	}, nil
 }
 
@@ -116,7 +257,6 @@ func (p *Parser) parseFunctionStatement(kind string) (*ast.FunctionStatement, er
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.consume(token.TokenTypeRightParen, fmt.Sprintf("expected `)` after %s parameters", kind))
 
 	body, err := p.parseBlockStatement()
 	if err != nil {
@@ -130,22 +270,26 @@ func (p *Parser) parseFunctionStatement(kind string) (*ast.FunctionStatement, er
 	}, nil
 }
 
+// parseParameters parses the parameter list after `(`, including the closing `)`
 func (p *Parser) parseParameters(kind string) ([]token.Token, error) {
 	parameters := make([]token.Token, 0)
-	for !p.currentTokenIs(token.TokenTypeRightParen) {
+	if !p.currentTokenIs(token.TokenTypeRightParen) {
 		parameter, err := p.consume(token.TokenTypeIdentifier, fmt.Sprintf("expected parameter name for %s", kind))
 		if err != nil {
 			return nil, err
 		}
 		parameters = append(parameters, parameter)
 
-		for !p.currentTokenIs(token.TokenTypeRightParen) {
-			_, err = p.consume(token.TokenTypeComma, fmt.Sprintf("expected `,` after argument for %s", kind))
+		for p.currentTokenIs(token.TokenTypeComma) {
+			_, err = p.advance()
 			if err != nil {
 				return nil, err
 			}
 
-			parameter, err := p.consume(token.TokenTypeIdentifier, fmt.Sprintf("expected parameter name for %s", kind))
+			if p.currentTokenIs(token.TokenTypeRightParen) {
+				return nil, fmt.Errorf("trailing `,` is not allowed in parameters of %s", kind)
+			}
+			parameter, err = p.consume(token.TokenTypeIdentifier, fmt.Sprintf("expected parameter name for %s", kind))
 			if err != nil {
 				return nil, err
 			}
@@ -153,6 +297,11 @@ func (p *Parser) parseParameters(kind string) ([]token.Token, error) {
 		}
 	}
 
+	_, err := p.consume(token.TokenTypeRightParen, fmt.Sprintf("expected `)` after %s parameters", kind))
+	if err != nil {
+		return nil, err
+	}
+
 	return parameters, nil
 }
 
@@ -166,7 +315,6 @@ func (p *Parser) parseVarDeclaration() (ast.Stmt, error) {
 		}
 	}
 
-	// TODO: do synchronize when the parser goes into panic mode.
 	if !p.currentTokenIs(token.TokenTypeIdentifier) {
 		return nil, fmt.Errorf("expected identifier but got token %s", p.currentToken().Type)
 	}
@@ -204,6 +352,9 @@ func (p *Parser) ParseStatement() (ast.Stmt, error) {
 	case token.TokenTypeIf:
 		return p.parseIfStatement()
 	case token.TokenTypePrint:
+		if p.isPrintCall() {
+			return p.parseExpressionStatement()
+		}
 		return p.parsePrintStatement()
 	case token.TokenTypeLeftBrace:
 		return p.parseBlockStatement()
@@ -213,6 +364,16 @@ func (p *Parser) ParseStatement() (ast.Stmt, error) {
 		return p.parseForStatement()
 	case token.TokenTypeReturn:
 		return p.parseReturnStatement()
+	case token.TokenTypeDefer:
+		return p.parseDeferStatement()
+	case token.TokenTypeRepeat:
+		return p.parseRepeatStatement()
+	case token.TokenTypeWhen:
+		return p.parseWhenStatement()
+	case token.TokenTypeBreak:
+		return p.parseBreakStatement()
+	case token.TokenTypeContinue:
+		return p.parseContinueStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -245,17 +406,40 @@ func (p *Parser) parseReturnStatement() (ast.Stmt, error) {
 	}, nil
 }
 
+func (p *Parser) parseDeferStatement() (ast.Stmt, error) {
+	if !p.currentTokenIs(token.TokenTypeDefer) {
+		return nil, fmt.Errorf("expected `defer` but got token %s", p.currentToken().Type)
+	}
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeSemicolon, "expect `;` after defer statement")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.DeferStatement{
+		Keyword:    keyword,
+		Expression: expr,
+	}, nil
+}
+
 func (p *Parser) parseForStatement() (ast.Stmt, error) {
 	if !p.currentTokenIs(token.TokenTypeFor) {
 		return nil, fmt.Errorf("expected `for` but got token %s", p.currentToken().Type)
-	} else {
-		_, err := p.advance()
-		if err != nil {
-			return nil, err
-		}
+	}
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := p.consume(token.TokenTypeLeftParen, "expect '(' after `for`")
+	_, err = p.consume(token.TokenTypeLeftParen, "expect '(' after `for`")
 	if err != nil {
 		return nil, err
 	}
@@ -303,49 +487,61 @@ func (p *Parser) parseForStatement() (ast.Stmt, error) {
 	}
 
 	body, err := p.ParseStatement()
-
-	if increment != nil {
-		body = &ast.BlockStatement{
-			Statements: []ast.Stmt{
-				body,
-				&ast.ExpressionStatement{
-					Expression: increment,
-				},
-			},
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	if condition == nil {
-		condition = &ast.LiteralExpression{Value: true}
+	return &ast.ForStatement{
+		Keyword:     keyword,
+		Initializer: initializer,
+		Condition:   condition,
+		Increment:   increment,
+		Body:        body,
+	}, nil
+}
+
+func (p *Parser) parseBreakStatement() (ast.Stmt, error) {
+	if !p.currentTokenIs(token.TokenTypeBreak) {
+		return nil, fmt.Errorf("expected `break` but got token %s", p.currentToken().Type)
 	}
-	body = &ast.WhileStatement{
-		Condition: condition,
-		Body:      body,
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
 	}
 
-	if initializer != nil {
-		body = &ast.BlockStatement{
-			Statements: []ast.Stmt{
-				initializer,
-				body,
-			},
-		}
+	_, err = p.consume(token.TokenTypeSemicolon, "expect `;` after break statement")
+	if err != nil {
+		return nil, err
 	}
+	return &ast.BreakStatement{Keyword: keyword}, nil
+}
 
-	return body, nil
+func (p *Parser) parseContinueStatement() (ast.Stmt, error) {
+	if !p.currentTokenIs(token.TokenTypeContinue) {
+		return nil, fmt.Errorf("expected `continue` but got token %s", p.currentToken().Type)
+	}
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeSemicolon, "expect `;` after continue statement")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.ContinueStatement{Keyword: keyword}, nil
 }
 
 func (p *Parser) parseWhileStatement() (ast.Stmt, error) {
 	if !p.currentTokenIs(token.TokenTypeWhile) {
 		return nil, fmt.Errorf("expected `while` but got token %s", p.currentToken().Type)
-	} else {
-		_, err := p.advance()
-		if err != nil {
-			return nil, err
-		}
+	}
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := p.consume(token.TokenTypeLeftParen, "expect '(' after `while`")
+	_, err = p.consume(token.TokenTypeLeftParen, "expect '(' after `while`")
 	if err != nil {
 		return nil, err
 	}
@@ -366,11 +562,76 @@ func (p *Parser) parseWhileStatement() (ast.Stmt, error) {
 	}
 
 	return &ast.WhileStatement{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
 	}, nil
 }
 
+func (p *Parser) parseRepeatStatement() (ast.Stmt, error) {
+	if !p.currentTokenIs(token.TokenTypeRepeat) {
+		return nil, fmt.Errorf("expected `repeat` but got token %s", p.currentToken().Type)
+	}
+	keyword, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeLeftParen, "expect '(' after `repeat`")
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeRightParen, "expect ')' after `repeat` count")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.ParseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.RepeatStatement{
+		Keyword: keyword,
+		Count:   count,
+		Body:    body,
+	}, nil
+}
+
+// parseWhenStatement parses `when cond: stmt`, it's sugar for `if (cond) stmt` without an else branch
+func (p *Parser) parseWhenStatement() (ast.Stmt, error) {
+	_, err := p.consume(token.TokenTypeWhen, "expected `when`")
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeColon, "expect ':' after `when` condition")
+	if err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.ParseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.IfStatement{
+		Condition:  condition,
+		ThenBranch: thenBranch,
+	}, nil
+}
+
 func (p *Parser) parseIfStatement() (ast.Stmt, error) {
 	if !p.currentTokenIs(token.TokenTypeIf) {
 		return nil, fmt.Errorf("expected `if` but got token %s", p.currentToken().Type)
@@ -425,6 +686,29 @@ func (p *Parser) parseIfStatement() (ast.Stmt, error) {
 	}, nil
 }
 
+// isPrintCall reports whether the statement starting with `print` is a call like `print(a, b);`.
+// `print (a) * 2;` is still a print statement, so the closing `)` must be followed by `;`.
+func (p *Parser) isPrintCall() bool {
+	if !p.nextTokenIs(token.TokenTypeLeftParen) {
+		return false
+	}
+
+	depth := 0
+	for n := 1; ; n++ {
+		switch p.peek(n).Type {
+		case token.TokenTypeLeftParen:
+			depth++
+		case token.TokenTypeRightParen:
+			depth--
+			if depth == 0 {
+				return p.peek(n+1).Type == token.TokenTypeSemicolon
+			}
+		case token.TokenTypeEOF:
+			return false
+		}
+	}
+}
+
 func (p *Parser) parsePrintStatement() (ast.Stmt, error) {
 	if !p.currentTokenIs(token.TokenTypePrint) {
 		return nil, fmt.Errorf("expected `print` but got token %s", p.currentToken().Type)
@@ -435,7 +719,8 @@ func (p *Parser) parsePrintStatement() (ast.Stmt, error) {
 		}
 	}
 
-	expr, err := p.parseExpression()
+	// commas separate the printed values even when the comma operator is disabled
+	expr, err := p.parseCommaExpression()
 	if err != nil {
 		return nil, err
 	}
@@ -455,13 +740,17 @@ func (p *Parser) parseBlockStatement() (*ast.BlockStatement, error) {
 		return nil, fmt.Errorf("expected `{` but got token %s", p.currentToken().Type)
 	}
 
-	_, err := p.advance()
+	leftBrace, err := p.advance()
 	if err != nil {
 		return nil, err
 	}
 
 	statements := make([]ast.Stmt, 0)
 	for !p.currentTokenIs(token.TokenTypeRightBrace) {
+		if p.currentTokenIs(token.TokenTypeEOF) {
+			return nil, fmt.Errorf("expected '}' to close block opened at line %d", leftBrace.Line)
+		}
+
 		stmt, err := p.ParseDeclaration()
 		if err != nil {
 			return nil, err
@@ -496,6 +785,10 @@ func (p *Parser) parseExpressionStatement() (ast.Stmt, error) {
 }
 
 func (p *Parser) parseExpression() (ast.Expr, error) {
+	if !p.EnableCommaOperator {
+		return p.parseAssignment()
+	}
+
 	return p.parseCommaExpression()
 }
 
@@ -557,6 +850,8 @@ func (p *Parser) parseAssignment() (ast.Expr, error) {
 				Name:   getExpr.Name,
 				Value:  val,
 			}, nil
+		} else if _, ok := expr.(*ast.ThisExpression); ok {
+			return nil, errors.New("Cannot assign to 'this'.")
 		} else {
 			return nil, fmt.Errorf("invalid assignment target %T", expr)
 
@@ -582,7 +877,8 @@ func (p *Parser) parseTernary() (ast.Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	consequent, err := p.parseExpression()
+	// the branches stop before a comma, `a ? b : c, d` is a comma expression of the ternary and d
+	consequent, err := p.parseAssignment()
 	if err != nil {
 		return nil, err
 	}
@@ -594,7 +890,8 @@ func (p *Parser) parseTernary() (ast.Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	alternative, err := p.parseExpression()
+	// parsing the alternative as a ternary makes `a ? b : c ? d : e` group as `a ? b : (c ? d : e)`
+	alternative, err := p.parseTernary()
 	if err != nil {
 		return nil, err
 	}
@@ -688,33 +985,49 @@ func (p *Parser) ParseEquality() (ast.Expr, error) {
 	return left, nil
 }
 
-func (p *Parser) currentToken() token.Token {
-	if p.current >= len(p.tokens) {
+// peek returns the token n positions ahead of the current one, or an EOF token past the end of input
+func (p *Parser) peek(n int) token.Token {
+	p.fill(n)
+	if p.current+n >= len(p.tokens) {
 		return token.Token{
 			Type: token.TokenTypeEOF,
 		}
 	}
 
-	return p.tokens[p.current]
+	return p.tokens[p.current+n]
 }
 
-func (p *Parser) currentTokenIs(tokenTypes ...token.TokenType) bool {
-	if p.current >= len(p.tokens) {
-		return false
-	}
+func (p *Parser) currentToken() token.Token {
+	return p.peek(0)
+}
 
-	return slices.Contains(tokenTypes, p.currentToken().Type)
+func (p *Parser) currentTokenIs(tokenTypes ...token.TokenType) bool {
+	return slices.Contains(tokenTypes, p.peek(0).Type)
 }
 
 func (p *Parser) nextTokenIs(tokenTypes ...token.TokenType) bool {
-	if p.current+1 >= len(p.tokens) {
-		return false
-	}
+	return slices.Contains(tokenTypes, p.peek(1).Type)
+}
 
-	return slices.Contains(tokenTypes, p.tokens[p.current+1].Type)
+// fill pulls tokens from source until the token n positions ahead of the current one is buffered
+func (p *Parser) fill(n int) {
+	for p.source != nil && p.current+n >= len(p.tokens) {
+		t, err := p.source.Next()
+		if err != nil {
+			p.sourceErr = err
+			p.source = nil
+			return
+		}
+		if t.IsTokenType(token.TokenTypeEOF) {
+			p.source = nil
+			return
+		}
+		p.tokens = append(p.tokens, t)
+	}
 }
 
 func (p *Parser) advance() (token.Token, error) {
+	p.fill(0)
 	if p.current >= len(p.tokens) {
 		return token.Token{}, errors.New("unexpected end of input")
 	}
@@ -839,7 +1152,31 @@ func (p *Parser) parseUnary() (ast.Expr, error) {
 		}, nil
 	}
 
-	return p.parseCall()
+	return p.parsePostfix()
+}
+
+// parsePostfix parses `target++` and `target--`, the target must be a variable or a property
+func (p *Parser) parsePostfix() (ast.Expr, error) {
+	expr, err := p.parseCall()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.currentTokenIs(token.TokenTypePlusPlus, token.TokenTypeMinusMinus) {
+		return expr, nil
+	}
+
+	op, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.(type) {
+	case *ast.VariableExpression, *ast.GetExpression:
+		return &ast.PostfixExpression{Target: expr, Operator: op}, nil
+	default:
+		return nil, fmt.Errorf("[line %d] can't apply `%s` here, only a variable or a property can be updated", op.Line, op.Lexeme)
+	}
 }
 
 func (p *Parser) parseCall() (ast.Expr, error) {
@@ -915,27 +1252,27 @@ func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
 
 func (p *Parser) parsePrimary() (ast.Expr, error) {
 	if p.currentTokenIs(token.TokenTypeTrue) {
-		_, err := p.advance()
+		t, err := p.advance()
 		if err != nil {
 			return nil, err
 		}
-		return &ast.LiteralExpression{Value: true}, nil
+		return &ast.LiteralExpression{Value: true, Token: t}, nil
 	}
 
 	if p.currentTokenIs(token.TokenTypeFalse) {
-		_, err := p.advance()
+		t, err := p.advance()
 		if err != nil {
 			return nil, err
 		}
-		return &ast.LiteralExpression{Value: false}, nil
+		return &ast.LiteralExpression{Value: false, Token: t}, nil
 	}
 
 	if p.currentTokenIs(token.TokenTypeNil) {
-		_, err := p.advance()
+		t, err := p.advance()
 		if err != nil {
 			return nil, err
 		}
-		return &ast.LiteralExpression{Value: nil}, nil
+		return &ast.LiteralExpression{Value: nil, Token: t}, nil
 	}
 
 	if p.currentTokenIs(token.TokenTypeSuper) {
@@ -977,7 +1314,7 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 
 		}
 
-		return &ast.LiteralExpression{Value: t.Literal}, nil
+		return &ast.LiteralExpression{Value: t.Literal, Token: t}, nil
 	}
 
 	if p.currentTokenIs(token.TokenTypeLeftParen) {
@@ -1005,10 +1342,18 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 	}
 
 	if p.currentTokenIs(token.TokenTypeFun) {
+		if p.nextTokenIs(token.TokenTypeIdentifier) {
+			return nil, fmt.Errorf("anonymous function can't have a name, got %s", p.peek(1).Lexeme)
+		}
 		return p.parseFunctionExpression()
 	}
 
-	if p.currentTokenIs(token.TokenTypeIdentifier) {
+	if p.currentTokenIs(token.TokenTypeMatch) {
+		return p.parseMatchExpression()
+	}
+
+	// `print` is also a builtin function, e.g. `print(a)` or `each(list, print)`
+	if p.currentTokenIs(token.TokenTypeIdentifier, token.TokenTypePrint) {
 		name, err := p.advance()
 		if err != nil {
 			return nil, err
@@ -1017,7 +1362,153 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 			Name: name,
 		}, nil
 	}
-	return nil, fmt.Errorf("expected expression got %s", p.currentToken().Type)
+	return nil, p.missingExpressionError()
+}
+
+// missingExpressionError explains why there is no expression at the current token, using the token before it as context
+func (p *Parser) missingExpressionError() error {
+	current := p.currentToken()
+	previous := token.Token{Type: token.TokenTypeEOF}
+	if p.current > 0 {
+		previous = p.tokens[p.current-1]
+	}
+
+	switch {
+	case previous.Type.IsOperator() && previous.Type != token.TokenTypeEqual:
+		return fmt.Errorf("expected an operand after `%s` but got %s", previous.Lexeme, describeToken(current))
+	case current.Type.IsOperator() && current.Type != token.TokenTypeMinus && current.Type != token.TokenTypeBang:
+		return fmt.Errorf("missing left operand before `%s`", current.Lexeme)
+	case current.Type == token.TokenTypeRightParen && previous.Type != token.TokenTypeLeftParen:
+		return errors.New("unmatched `)`, expected expression")
+	case p.current > 0:
+		return fmt.Errorf("expected expression after `%s` but got %s", previous.Lexeme, describeToken(current))
+	default:
+		return fmt.Errorf("expected expression but got %s", describeToken(current))
+	}
+}
+
+func describeToken(t token.Token) string {
+	if t.Type == token.TokenTypeEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("`%s`", t.Lexeme)
+}
+
+// parse match expression like match (x) { 1 => "one", _ => "other" }
+func (p *Parser) parseMatchExpression() (ast.Expr, error) {
+	keyword, err := p.consume(token.TokenTypeMatch, "expected `match`")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeLeftParen, "expected `(` after `match`")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(token.TokenTypeRightParen, "expected `)` after match subject")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.TokenTypeLeftBrace, "expected `{` before match arms")
+	if err != nil {
+		return nil, err
+	}
+	arms := make([]*ast.MatchArm, 0)
+	for !p.currentTokenIs(token.TokenTypeRightBrace) {
+		arm, err := p.parseMatchArm()
+		if err != nil {
+			return nil, err
+		}
+		arms = append(arms, arm)
+
+		if !p.currentTokenIs(token.TokenTypeComma) {
+			break
+		}
+		// a trailing comma after the last arm is allowed
+		_, err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = p.consume(token.TokenTypeRightBrace, "expected `}` after match arms")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MatchExpression{
+		Keyword: keyword,
+		Subject: subject,
+		Arms:    arms,
+	}, nil
+}
+
+// parseMatchArm parses `pattern => body` or `pattern if guard => body`,
+// a pattern is a literal, a negative number, the `_` wildcard or a name binding the value
+func (p *Parser) parseMatchArm() (*ast.MatchArm, error) {
+	var pattern ast.Expr
+	var binding *ast.VariableExpression
+	switch {
+	case p.currentToken().Type == token.TokenTypeIdentifier && p.currentToken().Lexeme == "_":
+		_, err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+	case p.currentTokenIs(token.TokenTypeIdentifier):
+		name, err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		binding = &ast.VariableExpression{Name: name}
+	case p.currentTokenIs(token.TokenTypeMinus) && p.nextTokenIs(token.TokenTypeNumber):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		pattern = expr
+	case p.currentToken().Type.IsLiteral():
+		expr, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		pattern = expr
+	default:
+		return nil, fmt.Errorf("expected a literal, a name or `_` as match pattern but got %s", describeToken(p.currentToken()))
+	}
+
+	var guard ast.Expr
+	if p.currentTokenIs(token.TokenTypeIf) {
+		_, err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		guard, err = p.parseAssignment()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := p.consume(token.TokenTypeArrow, "expected `=>` after match pattern")
+	if err != nil {
+		return nil, err
+	}
+
+	// commas separate the arms, so the body can't be a comma expression
+	body, err := p.parseAssignment()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MatchArm{
+		Pattern: pattern,
+		Binding: binding,
+		Guard:   guard,
+		Body:    body,
+	}, nil
 }
 
 // parse anonymous function like fun (a) { print a; }
@@ -1036,7 +1527,6 @@ func (p *Parser) parseFunctionExpression() (ast.Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.consume(token.TokenTypeRightParen, fmt.Sprintf("expected `)` after function parameters"))
 
 	body, err := p.parseBlockStatement()
 	if err != nil {
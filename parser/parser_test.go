@@ -1,10 +1,12 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ocowchun/go-lox/ast"
 	"github.com/ocowchun/go-lox/lexer"
+	"github.com/ocowchun/go-lox/token"
 )
 
 func TestParser_Parse(t *testing.T) {
@@ -17,15 +19,30 @@ func TestParser_Parse(t *testing.T) {
 		{"number", "1;", "1"},
 		{"plus expression", "1 + 2;", "(+ 1 2)"},
 		{"print statement", "print 1 + 2;", "(print (+ 1 2))"},
+		{"print statement with grouping", "print (1 + 2) * 3;", "(print (* (group (+ 1 2)) 3))"},
+		{"print call", "print(1 + 2);", "(print (+ 1 2))"},
+		{"print call with arguments", "print(1, 2);", "(print 1 2)"},
+		{"print passed as a function", "each(print);", "(each print)"},
 		{"var statement", "var a = 123;", "(define a 123)"},
 		{"block statement", "{ var a = 123; print a;}", "(begin\n(define a 123)\n(print a)\n)"},
 		{"if statement", "if (1 > 2) { print 1; }", "(if (> 1 2) (begin\n(print 1)\n))"},
 		{"if else statement", "if (a > b) { print a; } else { print b; }", "(if (> a b) (begin\n(print a)\n) (begin\n(print b)\n))"},
+		{"dangling else binds to the inner if", "if (a) if (b) print 1; else print 2;", "(if a (if b (print 1) (print 2)))"},
 		{"while statement", "while (i < 5) { i = i + 1;}", "(while (< i 5) (begin\n(set! i (+ i 1))\n))"},
-		{"for statement", "for (var i = 0; i < 5; i = i + 1) { print i;}", "(begin\n(define i 0)\n(while (< i 5) (begin\n(begin\n(print i)\n)\n(set! i (+ i 1))\n))\n)"},
+		{"for statement", "for (var i = 0; i < 5; i = i + 1) { print i;}", "(for (define i 0) (< i 5) (set! i (+ i 1)) (begin\n(print i)\n))"},
+		{"for statement without clauses", "for (;;) break;", "(for _ _ _ (break))"},
+		{"continue statement", "while (true) continue;", "(while true (continue))"},
+		{"function statement without parameters", "fun foo() { print 1; }", "(define (foo)\n(print 1)\n)"},
+		{"function statement with one parameter", "fun foo(a) { print a; }", "(define (foo a)\n(print a)\n)"},
 		{"function statement", "fun foo(a, b) { print a + b; }", "(define (foo a b)\n(print (+ a b))\n)"},
 		{"return statement", "return 1 + 2;", "(return (+ 1 2))"},
+		{"bare return statement", "return;", "(return)"},
+		{"defer statement", "defer foo(1);", "(defer (foo 1))"},
+		{"when statement", "when a > 1: print a;", "(if (> a 1) (print a))"},
+		{"when statement with a block", "when ready: { print 1; }", "(if ready (begin\n(print 1)\n))"},
+		{"repeat statement", "repeat (3) { print \"hi\"; }", "(repeat 3 (begin\n(print hi)\n))"},
 		{"class statement", "class Foo { bar() { print 123; } }", "(class Foo\n(define (bar)\n(print 123)\n)\n)"},
+		{"class statement with a static method", "class Math { class square(n) { return n * n; } }", "(class Math\n(static (define (square n)\n(return (* n n))\n))\n)"},
 		{"class statement with super class", "class Foo < Bar { bar() { print 123; } }", "(class Foo < Bar\n(define (bar)\n(print 123)\n)\n)"},
 	}
 
@@ -83,16 +100,32 @@ func TestParser_parseExpression(t *testing.T) {
 		{"different precedence case 2", "1 > 2 != 2 > 3", "(!= (> 1 2) (> 2 3))"},
 		{"comma operator", "1 + 1, 2", "(begin (+ 1 1) 2)"},
 		{"ternary operator", "1 > 2 ? 1 : 2", "(if (> 1 2) 1 2)"},
+		{"nested ternary is right-associative", "a ? b : c ? d : e", "(if a b (if c d e))"},
+		{"ternary binds tighter than comma", "a ? b : c, d", "(begin (if a b c) d)"},
 		{"assignment expression", "x = 1 + 2", "(set! x (+ 1 2))"},
+		{"assignment in comma operator", "a = 1, 2", "(begin (set! a 1) 2)"},
+		{"chained assignment is right-associative", "a = b = 5", "(set! a (set! b 5))"},
 		{"or expression", "a == b or a == c", "(or (== a b) (== a c))"},
 		{"and expression", "a == b and a == c", "(and (== a b) (== a c))"},
 		{"call expression 0", "foo()", "(foo)"},
 		{"call expression 1", "foo(1)", "(foo 1)"},
 		{"call expression 2", "foo(1, 2)", "(foo 1 2)"},
 		{"function expression", "fun (a) { print a; }", "(lambda (a) (begin\n(print a)\n))"},
+		{"function expression without parameters", "fun () { return 1; }", "(lambda () (begin\n(return 1)\n))"},
+		{"function expression with parameters", "fun (a, b, c) { return a; }", "(lambda (a b c) (begin\n(return a)\n))"},
+		{"function expression as argument", "foo(fun (a) { return a; })", "(foo (lambda (a) (begin\n(return a)\n)))"},
+		{"immediately invoked function expression", "(fun (x) { return x * 2; })(21)", "((group (lambda (x) (begin\n(return (* x 2))\n))) 21)"},
 		{"get expression", "a.b", "(get a b)"},
 		{"this expression", "this", "(this)"},
 		{"super expression", "super.foo", "(super foo)"},
+		{"postfix increment", "i++", "(post++ i)"},
+		{"postfix decrement", "i--", "(post-- i)"},
+		{"postfix increment on property", "a.b++", "(post++ (get a b))"},
+		{"postfix on a call result property", "f().b++", "(post++ (get (f) b))"},
+		{"minus minus between numbers is a subtraction", "1--1", "(- 1 (- 1))"},
+		{"subtracting a negative number", "1 - -1", "(- 1 (- 1))"},
+		{"match arm with a guard", `match (x) { n if n > 0 => n, _ if x == 0 => 0, _ => -1 }`, "(match x (n if (> n 0) n) (_ if (== x 0) 0) (_ (- 1)))"},
+		{"match expression", `match (x) { 1 => "one", -1 => "minus one", _ => "other", }`, "(match x (1 one) ((- 1) minus one) (_ other))"},
 	}
 
 	for _, testCase := range testCases {
@@ -126,6 +159,13 @@ func TestParseInvalidExpression(t *testing.T) {
 		expected string
 	}{
 		{"number", "1 + !", "1"},
+		{"named function expression", "var f = fun foo() {};", ""},
+		{"trailing comma in parameters", "fun foo(a,) {}", ""},
+		{"trailing comma in anonymous function parameters", "var f = fun (a, b,) {};", ""},
+		{"missing comma between parameters", "fun foo(a b) {}", ""},
+		{"unclosed parameters", "fun foo(a {}", ""},
+		{"increment literal", "1++;", ""},
+		{"increment call", "foo()++;", ""},
 	}
 
 	for _, testCase := range testCases {
@@ -145,3 +185,320 @@ func TestParseInvalidExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePostfixInvalidTarget(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"increment grouping", "(1)++;", "[line 1] can't apply `++` here, only a variable or a property can be updated"},
+		{"decrement call", "f()--;", "[line 1] can't apply `--` here, only a variable or a property can be updated"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			p, err := NewParserFromSource(testCase.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, err = p.Parse()
+			if err == nil || err.Error() != testCase.expected {
+				t.Errorf("Expected error %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestParser_peek(t *testing.T) {
+	tokens, err := lexer.New("foo(1);").Tokens()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	p := NewParser(tokens)
+	_, err = p.advance()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		n        int
+		expected token.TokenType
+	}{
+		{"current token", 0, token.TokenTypeLeftParen},
+		{"next token", 1, token.TokenTypeNumber},
+		{"two tokens ahead", 2, token.TokenTypeRightParen},
+		{"last token", 3, token.TokenTypeSemicolon},
+		{"past the end", 4, token.TokenTypeEOF},
+		{"far past the end", 100, token.TokenTypeEOF},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := p.peek(testCase.n)
+			if actual.Type != testCase.expected {
+				t.Errorf("Expected %s, got %s", testCase.expected, actual.Type)
+			}
+		})
+	}
+}
+
+func TestParser_FunctionExpressionKeepsFunToken(t *testing.T) {
+	tokens, err := lexer.New("var f =\n  fun (a) { return a; };").Tokens()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	statements, err := NewParser(tokens).Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	varStatement, ok := statements[0].(*ast.VarStatement)
+	if !ok {
+		t.Fatalf("Expected *ast.VarStatement, got %T", statements[0])
+	}
+	function, ok := varStatement.Initializer.(*ast.FunctionExpression)
+	if !ok {
+		t.Fatalf("Expected *ast.FunctionExpression, got %T", varStatement.Initializer)
+	}
+	if function.Fun.Type != token.TokenTypeFun || function.Fun.Line != 2 {
+		t.Errorf("Expected `fun` token at line 2, got %s at line %d", function.Fun.Type, function.Fun.Line)
+	}
+}
+
+func TestParser_TrailingCommaInParameters(t *testing.T) {
+	tokens, err := lexer.New("fun foo(a,) {}").Tokens()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = NewParser(tokens).Parse()
+	if err == nil {
+		t.Fatalf("Expected error, but got none")
+	}
+	if err.Error() != "trailing `,` is not allowed in parameters of function" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestParser_UnterminatedBlock(t *testing.T) {
+	tokens, err := lexer.New("var a = 1;\nif (a) {\n  print a;\n").Tokens()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = NewParser(tokens).Parse()
+	if err == nil {
+		t.Fatalf("Expected error, but got none")
+	}
+	if err.Error() != "expected '}' to close block opened at line 2" {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestParser_EnableCommaOperator(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		enabled  bool
+		expected string
+	}{
+		{"comma expression", "1, 2;", true, "(begin 1 2)"},
+		{"call arguments", "foo(1, 2);", true, "(foo 1 2)"},
+		{"call arguments without the comma operator", "foo(1, 2);", false, "(foo 1 2)"},
+		{"print values", "print 1, 2, 3;", true, "(print (begin 1 2 3))"},
+		{"print values without the comma operator", "print 1, 2, 3;", false, "(print (begin 1 2 3))"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tokens, err := lexer.New(testCase.input).Tokens()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			p := NewParser(tokens)
+			p.EnableCommaOperator = testCase.enabled
+
+			statements, err := p.Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse %s, error: %v", testCase.input, err)
+			}
+
+			printer := ast.Printer{}
+			actual := printer.PrintStatement(statements[0])
+			if actual != testCase.expected {
+				t.Errorf("Expected %s, got %s", testCase.expected, actual)
+			}
+		})
+	}
+
+	t.Run("comma expression without the comma operator", func(t *testing.T) {
+		tokens, err := lexer.New("1, 2;").Tokens()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		p := NewParser(tokens)
+		p.EnableCommaOperator = false
+
+		_, err = p.Parse()
+		if err == nil {
+			t.Errorf("Expected error, but got none")
+		}
+	})
+}
+
+func TestParser_MissingExpressionMessages(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"* 3;", "missing left operand before `*`"},
+		{"print 1 == > 2;", "expected an operand after `==` but got `>`"},
+		{"(1 +);", "expected an operand after `+` but got `)`"},
+		{"1 +", "expected an operand after `+` but got end of input"},
+		{"print );", "unmatched `)`, expected expression"},
+		{"if () print 1;", "expected expression after `(` but got `)`"},
+		{"var a = ;", "expected expression after `=` but got `;`"},
+		{"while (x <) {}", "expected an operand after `<` but got `)`"},
+		{"print ;", "expected expression after `print` but got `;`"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			tokens, err := lexer.New(testCase.input).Tokens()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, err = NewParser(tokens).Parse()
+			if err == nil {
+				t.Fatalf("Expected error, but got none")
+			}
+			if err.Error() != testCase.expected {
+				t.Errorf("Expected %q, got %q", testCase.expected, err.Error())
+			}
+		})
+	}
+}
+
+// countingSource records how many tokens the parser pulled from the lexer
+type countingSource struct {
+	lexer  *lexer.Lexer
+	pulled int
+}
+
+func (s *countingSource) Next() (token.Token, error) {
+	s.pulled++
+	return s.lexer.Next()
+}
+
+func TestParser_StreamingTokenSource(t *testing.T) {
+	source := &countingSource{lexer: lexer.New("var a = 1;\nprint a + 2;\nprint a * 3;\n")}
+	p := NewStreamingParser(source)
+
+	stmt, err := p.ParseDeclaration()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual := (&ast.Printer{}).PrintStatement(stmt); actual != "(define a 1)" {
+		t.Errorf("Expected (define a 1), got %s", actual)
+	}
+	// var a = 1; and a token of lookahead at most
+	if source.pulled > 6 {
+		t.Errorf("Expected the parser to pull tokens lazily, pulled %d", source.pulled)
+	}
+
+	statements, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(statements))
+	}
+	if actual := (&ast.Printer{}).PrintStatement(statements[1]); actual != "(print (* a 3))" {
+		t.Errorf("Expected (print (* a 3)), got %s", actual)
+	}
+}
+
+func TestParser_StreamingTokenSourceError(t *testing.T) {
+	_, err := NewStreamingParser(lexer.New("print 1 + @;")).Parse()
+	if err == nil {
+		t.Fatalf("Expected error, but got none")
+	}
+	if err.Error() != "[line 1] unexpected character '@'" {
+		t.Errorf("Expected the lexer error, got %v", err)
+	}
+}
+
+func TestNewParserFromSource(t *testing.T) {
+	p, err := NewParserFromSource("print 1 + 2;")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stmt, err := p.ParseDeclaration()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actual := (&ast.Printer{}).PrintStatement(stmt); actual != "(print (+ 1 2))" {
+		t.Errorf("Expected (print (+ 1 2)), got %s", actual)
+	}
+
+	_, err = NewParserFromSource("print @;")
+	if err == nil {
+		t.Fatalf("Expected the lexer error, but got none")
+	}
+}
+
+func TestParser_AssignToThis(t *testing.T) {
+	p, err := NewParserFromSource("class Foo { reset() { this = nil; } }")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = p.Parse()
+	if err == nil {
+		t.Fatalf("Expected error, but got none")
+	}
+	if err.Error() != "Cannot assign to 'this'." {
+		t.Errorf("Expected specific error message, got %v", err)
+	}
+}
+
+func TestParser_ReportsEverySyntaxError(t *testing.T) {
+	code := `var x = ;
+print 1;
+class Foo { reset() { this = nil; } }
+for (var i = ; i < 3; i = i + 1) { print i; }
+var y = ;
+print 2;
+`
+	p, err := NewParserFromSource(code)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = p.Parse()
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected joined errors, got %v", err)
+	}
+
+	expectedLines := []int{1, 3, 4, 5}
+	errs := joined.Unwrap()
+	if len(errs) != len(expectedLines) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expectedLines), len(errs), err)
+	}
+	for i, err := range errs {
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Fatalf("Expected ParseError, got %T", err)
+		}
+		if parseError.Token.Line != expectedLines[i] {
+			t.Errorf("Expected error %q on line %d, got line %d", parseError.Message, expectedLines[i], parseError.Token.Line)
+		}
+	}
+}
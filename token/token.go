@@ -1,6 +1,9 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 type TokenType int
 
@@ -45,6 +48,17 @@ const (
 	TokenTypeWhile
 	TokenTypeQuestionMark
 	TokenTypeColon
+	TokenTypeDefer
+	TokenTypePlusPlus
+	TokenTypeMinusMinus
+	TokenTypeRepeat
+	TokenTypeMatch
+	TokenTypeArrow
+	TokenTypeWhen
+	TokenTypeBreak
+	TokenTypeContinue
+	TokenTypeLineComment
+	TokenTypeBlockComment
 	TokenTypeEOF
 )
 
@@ -130,6 +144,28 @@ func (t TokenType) String() string {
 		return "QUESTION_MARK"
 	case TokenTypeColon:
 		return "COLON"
+	case TokenTypeDefer:
+		return "DEFER"
+	case TokenTypePlusPlus:
+		return "PLUS_PLUS"
+	case TokenTypeMinusMinus:
+		return "MINUS_MINUS"
+	case TokenTypeRepeat:
+		return "REPEAT"
+	case TokenTypeMatch:
+		return "MATCH"
+	case TokenTypeArrow:
+		return "ARROW"
+	case TokenTypeWhen:
+		return "WHEN"
+	case TokenTypeBreak:
+		return "BREAK"
+	case TokenTypeContinue:
+		return "CONTINUE"
+	case TokenTypeLineComment:
+		return "LINE_COMMENT"
+	case TokenTypeBlockComment:
+		return "BLOCK_COMMENT"
 	case TokenTypeEOF:
 		return "EOF"
 	default:
@@ -137,6 +173,144 @@ func (t TokenType) String() string {
 	}
 }
 
+// Lexeme returns the canonical source text of a token type,
+// it's empty for token types without a fixed lexeme like identifiers, numbers and strings.
+func (t TokenType) Lexeme() string {
+	switch t {
+	case TokenTypeLeftParen:
+		return "("
+	case TokenTypeRightParen:
+		return ")"
+	case TokenTypeLeftBrace:
+		return "{"
+	case TokenTypeRightBrace:
+		return "}"
+	case TokenTypeComma:
+		return ","
+	case TokenTypeDot:
+		return "."
+	case TokenTypeMinus:
+		return "-"
+	case TokenTypePlus:
+		return "+"
+	case TokenTypeSemicolon:
+		return ";"
+	case TokenTypeSlash:
+		return "/"
+	case TokenTypeStar:
+		return "*"
+	case TokenTypeBang:
+		return "!"
+	case TokenTypeBangEqual:
+		return "!="
+	case TokenTypeEqual:
+		return "="
+	case TokenTypeEqualEqual:
+		return "=="
+	case TokenTypeGreater:
+		return ">"
+	case TokenTypeGreaterEqual:
+		return ">="
+	case TokenTypeLess:
+		return "<"
+	case TokenTypeLessEqual:
+		return "<="
+	case TokenTypeQuestionMark:
+		return "?"
+	case TokenTypeColon:
+		return ":"
+	case TokenTypePlusPlus:
+		return "++"
+	case TokenTypeMinusMinus:
+		return "--"
+	case TokenTypeArrow:
+		return "=>"
+	}
+
+	for lexeme, tokenType := range keywords {
+		if tokenType == t {
+			return lexeme
+		}
+	}
+
+	return ""
+}
+
+var keywords = map[string]TokenType{
+	"and":      TokenTypeAnd,
+	"break":    TokenTypeBreak,
+	"class":    TokenTypeClass,
+	"continue": TokenTypeContinue,
+	"defer":    TokenTypeDefer,
+	"else":     TokenTypeElse,
+	"false":    TokenTypeFalse,
+	"for":      TokenTypeFor,
+	"fun":      TokenTypeFun,
+	"if":       TokenTypeIf,
+	"match":    TokenTypeMatch,
+	"nil":      TokenTypeNil,
+	"or":       TokenTypeOr,
+	"print":    TokenTypePrint,
+	"repeat":   TokenTypeRepeat,
+	"return":   TokenTypeReturn,
+	"super":    TokenTypeSuper,
+	"this":     TokenTypeThis,
+	"true":     TokenTypeTrue,
+	"var":      TokenTypeVar,
+	"when":     TokenTypeWhen,
+	"while":    TokenTypeWhile,
+}
+
+// Keyword returns the token type of a reserved word, keywords are case-sensitive.
+func Keyword(lexeme string) (TokenType, bool) {
+	tokenType, ok := keywords[lexeme]
+	return tokenType, ok
+}
+
+// IsKeyword reports whether t is a reserved word, true, false and nil included
+func (t TokenType) IsKeyword() bool {
+	for _, tokenType := range keywords {
+		if tokenType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOperator reports whether t is an operator symbol, the word operators `and` and `or` are keywords
+func (t TokenType) IsOperator() bool {
+	switch t {
+	case TokenTypeMinus, TokenTypePlus, TokenTypeSlash, TokenTypeStar,
+		TokenTypeBang, TokenTypeBangEqual, TokenTypeEqual, TokenTypeEqualEqual,
+		TokenTypeGreater, TokenTypeGreaterEqual, TokenTypeLess, TokenTypeLessEqual,
+		TokenTypePlusPlus, TokenTypeMinusMinus, TokenTypeQuestionMark, TokenTypeColon:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLiteral reports whether t is a literal value
+func (t TokenType) IsLiteral() bool {
+	switch t {
+	case TokenTypeNumber, TokenTypeString, TokenTypeTrue, TokenTypeFalse, TokenTypeNil:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPunctuation reports whether t groups or separates other tokens
+func (t TokenType) IsPunctuation() bool {
+	switch t {
+	case TokenTypeLeftParen, TokenTypeRightParen, TokenTypeLeftBrace, TokenTypeRightBrace,
+		TokenTypeComma, TokenTypeDot, TokenTypeSemicolon, TokenTypeArrow:
+		return true
+	default:
+		return false
+	}
+}
+
 type Token struct {
 	Type    TokenType
 	Lexeme  string
@@ -151,3 +325,30 @@ func (t Token) IsTokenType(targetType TokenType) bool {
 func (t Token) String() string {
 	return fmt.Sprintf("%s %s %v", t.Type, t.Lexeme, t.Literal)
 }
+
+// floatEqualityThreshold is the tolerance used when comparing number literals
+const floatEqualityThreshold = 1e-9
+
+// Equal reports whether two tokens have the same type, lexeme and literal, ignoring their lines.
+func (t Token) Equal(other Token) bool {
+	return Equal(t, other, false)
+}
+
+// Equal compares the type, lexeme and literal of two tokens, number literals are compared with a small tolerance.
+// Lines are only compared when compareLine is true.
+func Equal(a Token, b Token, compareLine bool) bool {
+	if a.Type != b.Type || a.Lexeme != b.Lexeme {
+		return false
+	}
+
+	if compareLine && a.Line != b.Line {
+		return false
+	}
+
+	if aNum, ok := a.Literal.(float64); ok {
+		bNum, ok := b.Literal.(float64)
+		return ok && math.Abs(aNum-bNum) <= floatEqualityThreshold
+	}
+
+	return a.Literal == b.Literal
+}
@@ -0,0 +1,132 @@
+package token
+
+import "testing"
+
+func TestToken_Equal(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        Token
+		b        Token
+		expected bool
+	}{
+		{"same token", Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 1}, Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 1}, true},
+		{"different line is ignored", Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 1}, Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 2}, true},
+		{"different type", Token{Type: TokenTypeIdentifier, Lexeme: "and"}, Token{Type: TokenTypeAnd, Lexeme: "and"}, false},
+		{"different lexeme", Token{Type: TokenTypeIdentifier, Lexeme: "foo"}, Token{Type: TokenTypeIdentifier, Lexeme: "bar"}, false},
+		{"same string literal", Token{Type: TokenTypeString, Lexeme: "a", Literal: "a"}, Token{Type: TokenTypeString, Lexeme: "a", Literal: "a"}, true},
+		{"different string literal", Token{Type: TokenTypeString, Lexeme: "a", Literal: "a"}, Token{Type: TokenTypeString, Lexeme: "a", Literal: "b"}, false},
+		{"number literal within tolerance", Token{Type: TokenTypeNumber, Lexeme: "0.3", Literal: 0.1 + 0.2}, Token{Type: TokenTypeNumber, Lexeme: "0.3", Literal: 0.3}, true},
+		{"number literal out of tolerance", Token{Type: TokenTypeNumber, Lexeme: "1", Literal: 1.0}, Token{Type: TokenTypeNumber, Lexeme: "1", Literal: 1.001}, false},
+		{"number and missing literal", Token{Type: TokenTypeNumber, Lexeme: "1", Literal: 1.0}, Token{Type: TokenTypeNumber, Lexeme: "1"}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.a.Equal(testCase.b); actual != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEqual_CompareLine(t *testing.T) {
+	a := Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 1}
+	b := Token{Type: TokenTypeIdentifier, Lexeme: "foo", Line: 2}
+
+	if Equal(a, b, true) {
+		t.Errorf("Expected tokens on different lines not to be equal")
+	}
+	if !Equal(a, a, true) {
+		t.Errorf("Expected tokens on the same line to be equal")
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	testCases := []struct {
+		name         string
+		lexeme       string
+		expectedType TokenType
+		expectedOk   bool
+	}{
+		{"known keyword", "while", TokenTypeWhile, true},
+		{"non keyword", "foo", 0, false},
+		{"keywords are case sensitive", "While", 0, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tokenType, ok := Keyword(testCase.lexeme)
+			if ok != testCase.expectedOk {
+				t.Fatalf("Expected ok to be %v, got %v", testCase.expectedOk, ok)
+			}
+			if ok && tokenType != testCase.expectedType {
+				t.Errorf("Expected %s, got %s", testCase.expectedType, tokenType)
+			}
+		})
+	}
+}
+
+func TestTokenType_Lexeme(t *testing.T) {
+	testCases := []struct {
+		tokenType TokenType
+		expected  string
+	}{
+		{TokenTypeLeftParen, "("},
+		{TokenTypeEqualEqual, "=="},
+		{TokenTypeLessEqual, "<="},
+		{TokenTypeAnd, "and"},
+		{TokenTypeWhile, "while"},
+		{TokenTypeIdentifier, ""},
+		{TokenTypeNumber, ""},
+		{TokenTypeString, ""},
+		{TokenTypeEOF, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.tokenType.String(), func(t *testing.T) {
+			if actual := testCase.tokenType.Lexeme(); actual != testCase.expected {
+				t.Errorf("Expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTokenType_Classification(t *testing.T) {
+	testCases := []struct {
+		tokenType   TokenType
+		keyword     bool
+		operator    bool
+		literal     bool
+		punctuation bool
+	}{
+		{TokenTypeFun, true, false, false, false},
+		{TokenTypeAnd, true, false, false, false},
+		{TokenTypeTrue, true, false, true, false},
+		{TokenTypeNil, true, false, true, false},
+		{TokenTypePlus, false, true, false, false},
+		{TokenTypeBangEqual, false, true, false, false},
+		{TokenTypeNumber, false, false, true, false},
+		{TokenTypeString, false, false, true, false},
+		{TokenTypeSemicolon, false, false, false, true},
+		{TokenTypeLeftBrace, false, false, false, true},
+		{TokenTypeIdentifier, false, false, false, false},
+		{TokenTypeEOF, false, false, false, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.tokenType.String(), func(t *testing.T) {
+			if actual := testCase.tokenType.IsKeyword(); actual != testCase.keyword {
+				t.Errorf("Expected IsKeyword() = %v, got %v", testCase.keyword, actual)
+			}
+			if actual := testCase.tokenType.IsOperator(); actual != testCase.operator {
+				t.Errorf("Expected IsOperator() = %v, got %v", testCase.operator, actual)
+			}
+			if actual := testCase.tokenType.IsLiteral(); actual != testCase.literal {
+				t.Errorf("Expected IsLiteral() = %v, got %v", testCase.literal, actual)
+			}
+			if actual := testCase.tokenType.IsPunctuation(); actual != testCase.punctuation {
+				t.Errorf("Expected IsPunctuation() = %v, got %v", testCase.punctuation, actual)
+			}
+		})
+	}
+}